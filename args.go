@@ -0,0 +1,433 @@
+package bearychat
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	requestType  = reflect.TypeOf(&OutgoingRequest{})
+	messageType  = reflect.TypeOf(&Message{})
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// argField is a field of an args struct, tagged by
+//
+//	arg:"0"            positional argument, a []string takes the rest
+//	flag:"env"         --env value, --env=value, or --env for a bool
+//	default:"staging"  positional arguments without default are required
+//	required:"true"    required flag
+//	enum:"a,b"         allowed values
+//	help:"..."         shown in the usage
+type argField struct {
+	index    []int
+	name     string
+	position int
+	flag     string
+	def      string
+	hasDef   bool
+	required bool
+	enum     []string
+	help     string
+	typ      reflect.Type
+}
+
+type argsParser struct {
+	typ        reflect.Type
+	positional []*argField
+	flags      []*argField
+}
+
+func newArgsParser(typ reflect.Type) (*argsParser, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("args type %s is not a struct", typ)
+	}
+
+	p := &argsParser{typ: typ}
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+
+		arg, isArg := sf.Tag.Lookup("arg")
+		flag, isFlag := sf.Tag.Lookup("flag")
+
+		if !isArg && !isFlag {
+			continue
+		}
+
+		if len(sf.PkgPath) > 0 {
+			return nil, fmt.Errorf("field %s: tagged field is not exported", sf.Name)
+		}
+
+		f := &argField{
+			index:    sf.Index,
+			name:     strings.ToLower(sf.Name),
+			flag:     flag,
+			help:     sf.Tag.Get("help"),
+			required: sf.Tag.Get("required") == "true",
+			typ:      sf.Type,
+		}
+
+		f.def, f.hasDef = sf.Tag.Lookup("default")
+
+		if enum := sf.Tag.Get("enum"); len(enum) > 0 {
+			f.enum = strings.Split(enum, ",")
+		}
+
+		if err := checkArgType(sf.Type); err != nil {
+			return nil, fmt.Errorf("field %s: %s", sf.Name, err.Error())
+		}
+
+		if isFlag {
+			p.flags = append(p.flags, f)
+			continue
+		}
+
+		position, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: bad arg position %q", sf.Name, arg)
+		}
+
+		f.position = position
+		f.required = !f.hasDef && sf.Type.Kind() != reflect.Slice
+
+		p.positional = append(p.positional, f)
+	}
+
+	for i, f := range p.positional {
+		if f.position != i {
+			return nil, fmt.Errorf("args of %s: positions must be 0 to %d in field order", typ, len(p.positional)-1)
+		}
+
+		if f.typ.Kind() == reflect.Slice && i != len(p.positional)-1 {
+			return nil, fmt.Errorf("args of %s: only the last positional argument may be a slice", typ)
+		}
+	}
+
+	return p, nil
+}
+
+func checkArgType(typ reflect.Type) error {
+	if typ == durationType {
+		return nil
+	}
+
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.String {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported type %s", typ)
+}
+
+// parse fills v, a pointer to the args struct, from args.
+func (p *argsParser) parse(args []string, v reflect.Value) error {
+	v = v.Elem()
+
+	seen := make(map[*argField]bool)
+
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// a bare -- ends the flags, e.g. to pass "--force" as an argument
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := arg[2:], "", false
+		if idx := strings.Index(name, "="); idx >= 0 {
+			name, value, hasValue = name[:idx], name[idx+1:], true
+		}
+
+		f := p.flag(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag --%s", name)
+		}
+
+		if !hasValue {
+			if f.typ.Kind() == reflect.Bool {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return fmt.Errorf("flag --%s needs a value", name)
+			}
+		}
+
+		if err := f.set(v, value); err != nil {
+			return err
+		}
+
+		seen[f] = true
+	}
+
+	for i, f := range p.positional {
+		if f.typ.Kind() == reflect.Slice {
+			if i < len(positional) {
+				for _, value := range positional[i:] {
+					if err := f.set(v, value); err != nil {
+						return err
+					}
+				}
+				positional = positional[:i]
+				seen[f] = true
+			}
+			continue
+		}
+
+		if i < len(positional) {
+			if err := f.set(v, positional[i]); err != nil {
+				return err
+			}
+			seen[f] = true
+		}
+	}
+
+	if len(positional) > len(p.positional) {
+		return fmt.Errorf("too many arguments: %s", strings.Join(positional[len(p.positional):], " "))
+	}
+
+	for _, f := range append(p.positional, p.flags...) {
+		if seen[f] {
+			continue
+		}
+
+		if f.required {
+			return fmt.Errorf("%s is required", f.display())
+		}
+
+		if !f.hasDef {
+			continue
+		}
+
+		// the default of a slice is a comma separated list
+		values := []string{f.def}
+		if f.typ.Kind() == reflect.Slice {
+			values = strings.Split(f.def, ",")
+		}
+
+		for _, value := range values {
+			if err := f.set(v, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *argsParser) flag(name string) *argField {
+	for _, f := range p.flags {
+		if f.flag == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (p *argField) display() string {
+	if len(p.flag) > 0 {
+		return "--" + p.flag
+	}
+	return p.name
+}
+
+func (p *argField) set(v reflect.Value, value string) error {
+	if len(p.enum) > 0 {
+		valid := false
+		for _, e := range p.enum {
+			valid = valid || e == value
+		}
+
+		if !valid {
+			return fmt.Errorf("%s must be one of %s, got %q", p.display(), strings.Join(p.enum, ", "), value)
+		}
+	}
+
+	field := v.FieldByIndex(p.index)
+
+	bad := func() error {
+		return fmt.Errorf("bad value of %s: %q is not a %s", p.display(), value, kindName(p.typ))
+	}
+
+	if p.typ == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return bad()
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch p.typ.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return bad()
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, p.typ.Bits())
+		if err != nil {
+			return bad()
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, p.typ.Bits())
+		if err != nil {
+			return bad()
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, p.typ.Bits())
+		if err != nil {
+			return bad()
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		field.Set(reflect.Append(field, reflect.ValueOf(value).Convert(p.typ.Elem())))
+	}
+
+	return nil
+}
+
+func kindName(typ reflect.Type) string {
+	switch {
+	case typ == durationType:
+		return "duration"
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		return "number"
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		return "integer"
+	}
+	return typ.Kind().String()
+}
+
+// usage returns the usage text of the command, e.g.
+//
+//	usage: !deploy <service> [--env staging]
+func (p *argsParser) usage(command string) string {
+	line := []string{"usage: " + command}
+
+	for _, f := range p.positional {
+		switch {
+		case f.typ.Kind() == reflect.Slice:
+			line = append(line, "["+f.name+"...]")
+		case f.required:
+			line = append(line, "<"+f.name+">")
+		default:
+			line = append(line, "["+f.name+"]")
+		}
+	}
+
+	for _, f := range p.flags {
+		flag := "--" + f.flag
+		if f.typ.Kind() != reflect.Bool {
+			flag += " " + f.name
+		}
+
+		if f.required {
+			line = append(line, flag)
+		} else {
+			line = append(line, "["+flag+"]")
+		}
+	}
+
+	lines := []string{strings.Join(line, " ")}
+
+	for _, f := range append(p.positional, p.flags...) {
+		desc := f.help
+
+		if len(f.enum) > 0 {
+			desc += " (" + strings.Join(f.enum, "|") + ")"
+		}
+
+		if f.hasDef {
+			desc += " (default " + f.def + ")"
+		}
+
+		lines = append(lines, strings.TrimRight(fmt.Sprintf("  %-12s %s", f.display(), strings.TrimSpace(desc)), " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// argsHandler calls a func(*OutgoingRequest, *T, *Message) error with the
+// arguments of the request parsed into a new T.
+type argsHandler struct {
+	fn      reflect.Value
+	parser  *argsParser
+	command string
+}
+
+func newArgsHandler(command string, fn interface{}) (*argsHandler, error) {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+
+	if t.Kind() != reflect.Func || t.NumIn() != 3 || t.NumOut() != 1 ||
+		t.In(0) != requestType || t.In(1).Kind() != reflect.Ptr || t.In(2) != messageType || t.Out(0) != errorType {
+		return nil, errors.New("handler must be a func(*bearychat.OutgoingRequest, *Args, *bearychat.Message) error")
+	}
+
+	parser, err := newArgsParser(t.In(1).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	return &argsHandler{fn: v, parser: parser, command: command}, nil
+}
+
+func (p *argsHandler) Handle(req *OutgoingRequest, msg *Message) error {
+	args := req.Args()
+
+	if len(args) == 1 && (args[0] == "help" || args[0] == "--help") {
+		msg.Text = p.parser.usage(p.command)
+		return nil
+	}
+
+	v := reflect.New(p.parser.typ)
+
+	if err := p.parser.parse(args, v); err != nil {
+		return UserError(errors.New(err.Error() + "\n" + p.parser.usage(p.command)))
+	}
+
+	out := p.fn.Call([]reflect.Value{reflect.ValueOf(req), v, reflect.ValueOf(msg)})
+
+	err, _ := out[0].Interface().(error)
+
+	return err
+}
+
+// HandleArgs binds fn, a func(*OutgoingRequest, *T, *Message) error, to the
+// word and sub-commands. The arguments are parsed into T by its struct tags
+// (see argField), "help" or "--help" replies with the usage.
+func (p *Outgoing) HandleArgs(word string, commands []string, fn interface{}, opts ...BindingOption) error {
+	handler, err := newArgsHandler(strings.Join(append([]string{strings.TrimSpace(word)}, commands...), " "), fn)
+	if err != nil {
+		return err
+	}
+
+	return p.HandleTrigger(word, commands, []Trigger{handler}, opts...)
+}
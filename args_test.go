@@ -0,0 +1,133 @@
+package bearychat
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+type deployArgs struct {
+	Service string        `arg:"0" help:"service to deploy"`
+	Hosts   []string      `arg:"1"`
+	Env     string        `flag:"env" default:"staging" enum:"staging,production"`
+	Force   bool          `flag:"force"`
+	Replica int           `flag:"replicas" default:"1"`
+	Timeout time.Duration `flag:"timeout" default:"5m"`
+}
+
+func TestHandleArgs(t *testing.T) {
+
+	outgoing, _ := NewOutgoing(configuration.ParseString(`{}`))
+
+	err := outgoing.HandleArgs("!deploy", nil, func(req *OutgoingRequest, args *deployArgs, msg *Message) error {
+		msg.Text = fmt.Sprintf("%s %v %s %v %d %s", args.Service, args.Hosts, args.Env, args.Force, args.Replica, args.Timeout)
+		return nil
+	})
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err = outgoing.HandleArgs("!bad", nil, func(args deployArgs) error { return nil }); err == nil {
+		t.Error("bad handler signature should be rejected")
+	}
+
+	send := func(text string) (string, error) {
+		msg := Message{}
+		err := outgoing.Handle(&OutgoingRequest{Text: text, TriggerWord: "!deploy"}, &msg)
+		return msg.Text, err
+	}
+
+	for text, expected := range map[string]string{
+		"!deploy web": "web [] staging false 1 5m0s",
+		"!deploy web a b --env production --force":             "web [a b] production true 1 5m0s",
+		"!deploy --replicas=3 web --timeout 30s":               "web [] staging false 3 30s",
+		"!deploy web --force=false --env=staging --replicas 2": "web [] staging false 2 5m0s",
+	} {
+		if text, err := send(text); err != nil || text != expected {
+			t.Errorf("expected %q, got %q (%v)", expected, text, err)
+		}
+	}
+
+	for _, text := range []string{
+		"!deploy",
+		"!deploy web --env dev",
+		"!deploy web --replicas many",
+		"!deploy web --unknown",
+		"!deploy web --env",
+	} {
+		_, err := send(text)
+		if KindOf(err) != ErrorKindUser || !strings.Contains(err.Error(), "usage: !deploy <service> [hosts...]") {
+			t.Errorf("%s: expected user error with usage, got: %v", text, err)
+		}
+	}
+
+	usage, err := send("!deploy help")
+	if err != nil || !strings.Contains(usage, "service to deploy") || !strings.Contains(usage, "(staging|production) (default staging)") {
+		t.Errorf("bad help: %q (%v)", usage, err)
+	}
+}
+
+type tagArgs struct {
+	Targets []string `arg:"0" default:"web,api" enum:"web,api,db"`
+	Tags    []string `flag:"tag" default:"a,b"`
+	Force   bool     `flag:"force"`
+}
+
+func TestHandleArgsEdges(t *testing.T) {
+
+	outgoing, _ := NewOutgoing(nil)
+
+	err := outgoing.HandleArgs("!tag", nil, func(req *OutgoingRequest, args *tagArgs, msg *Message) error {
+		msg.Text = fmt.Sprintf("%v %v %v", args.Targets, args.Tags, args.Force)
+		return nil
+	})
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	send := func(text string) (string, error) {
+		msg := Message{}
+		err := outgoing.Handle(&OutgoingRequest{Text: text, TriggerWord: "!tag"}, &msg)
+		return msg.Text, err
+	}
+
+	for text, expected := range map[string]string{
+		"!tag":                    "[web api] [a b] false",
+		"!tag db --tag x --tag y": "[db] [x y] false",
+		"!tag --force -- web":     "[web] [a b] true",
+		"!tag web -- --force":     "",
+		"!tag -- web db":          "[web db] [a b] false",
+		"!tag --tag=z api -- db":  "[api db] [z] false",
+	} {
+		text, err := send(text)
+		if len(expected) == 0 {
+			if err == nil || !strings.Contains(err.Error(), "--force") {
+				t.Errorf("--force after -- should be an argument checked by enum, got %q (%v)", text, err)
+			}
+			continue
+		}
+
+		if err != nil || text != expected {
+			t.Errorf("expected %q, got %q (%v)", expected, text, err)
+		}
+	}
+
+	if _, err := send("!tag web cache"); err == nil || !strings.Contains(err.Error(), "must be one of web, api, db") {
+		t.Errorf("slice positional arguments should be checked by enum, got: %v", err)
+	}
+
+	type unexported struct {
+		service string `arg:"0"`
+	}
+
+	if err := outgoing.HandleArgs("!bad", nil, func(req *OutgoingRequest, args *unexported, msg *Message) error { return nil }); err == nil {
+		t.Error("unexported tagged field should be rejected")
+	}
+}
@@ -0,0 +1,189 @@
+package bearychat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat/internal"
+	"github.com/gogap/bearychat/internal/rotate"
+)
+
+type AuditSink interface {
+	Audit(entry *AuditEntry) error
+}
+
+// AuditEntry is written to the audit sink after the drivers of a matched
+// trigger were executed, drivers add details by OutgoingRequest.Annotate.
+type AuditEntry struct {
+	Time       time.Time              `json:"time"`
+	ID         string                 `json:"request_id"`
+	Service    string                 `json:"service,omitempty"`
+	Team       string                 `json:"team,omitempty"`
+	User       string                 `json:"user"`
+	Channel    string                 `json:"channel"`
+	Word       string                 `json:"trigger_word"`
+	Commands   []string               `json:"commands,omitempty"`
+	Args       []string               `json:"args,omitempty"`
+	Drivers    []string               `json:"drivers"`
+	Outcome    string                 `json:"outcome"`
+	Error      string                 `json:"error,omitempty"`
+	Output     string                 `json:"output,omitempty"`
+	OutputHash string                 `json:"output_sha256,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+func newAuditEntry(req *OutgoingRequest, entry *RequestLog, msg *Message) *AuditEntry {
+	e := &AuditEntry{
+		Time:     entry.Time,
+		ID:       entry.ID,
+		Service:  entry.Service,
+		Team:     entry.Team,
+		User:     req.UserName,
+		Channel:  req.ChannelName,
+		Word:     entry.Word,
+		Commands: req.Commands,
+		Args:     req.Args(),
+		Outcome:  entry.Outcome,
+		Error:    entry.Error,
+		Output:   msg.Text,
+		Details:  req.Annotations(),
+	}
+
+	for _, d := range entry.Drivers {
+		e.Drivers = append(e.Drivers, d.Name)
+	}
+
+	return e
+}
+
+const (
+	AuditOutputHash     = "hash"
+	AuditOutputTruncate = "truncate"
+)
+
+// JSONAuditSink writes audit entries as JSON lines, the output of a command
+// is replaced by its sha256 or truncated to limit bytes.
+type JSONAuditSink struct {
+	w      io.Writer
+	output string
+	limit  int
+
+	sync.Mutex
+}
+
+func NewJSONAuditSink(w io.Writer, output string, limit int) *JSONAuditSink {
+	return &JSONAuditSink{w: w, output: output, limit: limit}
+}
+
+// truncateUTF8 cuts str to at most limit bytes without splitting a rune.
+func truncateUTF8(str string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	if limit >= len(str) {
+		return str
+	}
+
+	for limit > 0 && !utf8.RuneStart(str[limit]) {
+		limit--
+	}
+
+	return str[:limit]
+}
+
+func (p *JSONAuditSink) Audit(entry *AuditEntry) error {
+	e := *entry
+
+	switch p.output {
+	case AuditOutputHash:
+		if len(e.Output) > 0 {
+			sum := sha256.Sum256([]byte(e.Output))
+			e.OutputHash = hex.EncodeToString(sum[:])
+		}
+		e.Output = ""
+	case AuditOutputTruncate:
+		if len(e.Output) > p.limit {
+			e.Output = truncateUTF8(e.Output, p.limit)
+		}
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	_, err = p.w.Write(append(data, '\n'))
+
+	return err
+}
+
+func (p *JSONAuditSink) Close() error {
+	if closer, ok := p.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// NewAuditSink creates a JSON lines audit sink writing to a rotating file:
+//
+//	audit {
+//	    file        = "/var/log/outgoing/audit.jsonl"
+//	    max-size    = 100MB
+//	    max-backups = 10
+//	    output      = hash      // hash or truncate
+//	    truncate    = 512       // bytes of output kept by truncate
+//	}
+func NewAuditSink(config *configuration.Config) (*JSONAuditSink, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	filename := config.GetString("file")
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("audit.file is empty")
+	}
+
+	output := config.GetString("output", AuditOutputHash)
+	if output != AuditOutputHash && output != AuditOutputTruncate {
+		return nil, fmt.Errorf("unknown audit output: %s", output)
+	}
+
+	truncate := int(config.GetInt32("truncate", 512))
+	if truncate < 0 {
+		return nil, fmt.Errorf("audit.truncate must not be negative: %d", truncate)
+	}
+
+	maxSize, err := internal.ByteSize(config, "max-size", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := rotate.Open(filename, maxSize, int(config.GetInt32("max-backups", 10)))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONAuditSink(f, output, truncate), nil
+}
+
+func (p *Outgoing) audit(req *OutgoingRequest, entry *RequestLog, msg *Message) {
+	if p.settings.Audit == nil {
+		return
+	}
+
+	if err := p.settings.Audit.Audit(newAuditEntry(req, entry, msg)); err != nil {
+		log.Printf("[bearychat] write audit entry failed, request_id: %s, error: %s", entry.ID, err.Error())
+	}
+}
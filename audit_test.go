@@ -0,0 +1,109 @@
+package bearychat
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+type annotator struct{}
+
+func newAnnotator(word string, config *configuration.Config) (Trigger, error) {
+	return &annotator{}, nil
+}
+
+func (p *annotator) Handle(req *OutgoingRequest, msg *Message) error {
+	req.Annotate("exit_code", 3)
+	msg.Text = "secret output"
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		deploy {
+			word = "!ops"
+			commands = [deploy]
+			drivers = [test-annotator]
+		}
+	}`)
+
+	buf := bytes.NewBuffer(nil)
+
+	outgoing, err := NewOutgoing(config, RegistryOption(testRegistry()), AuditOption(NewJSONAuditSink(buf, AuditOutputHash, 0)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	outgoing.Handle(&OutgoingRequest{Text: "!ops deploy web", TriggerWord: "!ops", UserName: "zeal", ChannelName: "ops"}, &Message{})
+	outgoing.Handle(&OutgoingRequest{Text: "!unknown", TriggerWord: "!unknown", UserName: "zeal"}, &Message{})
+
+	var entries []AuditEntry
+
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		e := AuditEntry{}
+		if err = decoder.Decode(&e); err != nil {
+			t.Error(err)
+			return
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("only matched requests should be audited, got %d entries", len(entries))
+		return
+	}
+
+	e := entries[0]
+
+	sum := sha256.Sum256([]byte("secret output"))
+
+	if e.User != "zeal" || e.Channel != "ops" || len(e.Args) != 1 || e.Args[0] != "web" || e.Outcome != "ok" {
+		t.Errorf("bad audit entry: %+v", e)
+	}
+
+	if len(e.Output) > 0 || e.OutputHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("output should be hashed, got: %q %q", e.Output, e.OutputHash)
+	}
+
+	if code, _ := e.Details["exit_code"].(float64); code != 3 {
+		t.Errorf("bad audit details: %v", e.Details)
+	}
+}
+
+func TestAuditTruncate(t *testing.T) {
+
+	buf := bytes.NewBuffer(nil)
+
+	sink := NewJSONAuditSink(buf, AuditOutputTruncate, 4)
+	if err := sink.Audit(&AuditEntry{Output: "部署完成"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	entry := AuditEntry{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if entry.Output != "部" {
+		t.Errorf("output should be truncated on a rune boundary, got %q", entry.Output)
+	}
+
+	for limit, expected := range map[int]string{-1: "", 0: "", 3: "部", 100: "部署完成"} {
+		if out := truncateUTF8("部署完成", limit); out != expected {
+			t.Errorf("truncate to %d: expected %q, got %q", limit, expected, out)
+		}
+	}
+
+	if _, err := NewAuditSink(configuration.ParseString(`{ file = "audit.jsonl", output = truncate, truncate = -1 }`)); err == nil {
+		t.Error("negative truncate should be rejected")
+	}
+}
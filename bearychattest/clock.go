@@ -0,0 +1,39 @@
+package bearychattest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake clock, pass it to an Outgoing with
+// bearychat.ClockOption(clock.Now).
+type Clock struct {
+	now time.Time
+
+	sync.Mutex
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (p *Clock) Now() time.Time {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.now
+}
+
+func (p *Clock) Advance(d time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.now = p.now.Add(d)
+}
+
+func (p *Clock) Set(now time.Time) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.now = now
+}
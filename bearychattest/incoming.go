@@ -0,0 +1,34 @@
+package bearychattest
+
+import (
+	"net/http/httptest"
+
+	"github.com/gogap/bearychat"
+	"github.com/gogap/bearychat/incoming/fake"
+)
+
+// IncomingServer is a fake incoming webhook which captures the messages
+// posted to any of its paths, failures and latency are injected by Fake.
+type IncomingServer struct {
+	*httptest.Server
+
+	Fake *fake.Server
+}
+
+func NewIncomingServer(opts ...fake.Option) *IncomingServer {
+	s := fake.NewServer(opts...)
+
+	return &IncomingServer{
+		Server: httptest.NewServer(s),
+		Fake:   s,
+	}
+}
+
+// Messages returns the accepted messages.
+func (p *IncomingServer) Messages() []bearychat.Message {
+	return p.Fake.Messages()
+}
+
+func (p *IncomingServer) Reset() {
+	p.Fake.Reset()
+}
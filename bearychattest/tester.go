@@ -0,0 +1,167 @@
+// Package bearychattest provides helpers for testing trigger drivers.
+//
+//	tester := bearychattest.New(t, `{
+//	    deploy {
+//	        word = "!deploy"
+//	        drivers = [gogap-auth, my-driver]
+//	        gogap-auth.token = "secret"
+//	    }
+//	}`)
+//
+//	tester.Send("zeal", "ops", "!deploy web").ExpectText("deploying web")
+//
+// Every tester has its own fake clock passed to its Outgoing by
+// bearychat.ClockOption, so tests using it can run in parallel.
+package bearychattest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+// IncomingURL in the config is replaced by the url of the fake incoming
+// webhook of the tester.
+const IncomingURL = "$INCOMING_URL"
+
+type Tester struct {
+	Outgoing *bearychat.Outgoing
+	Clock    *Clock
+	Incoming *IncomingServer
+
+	t testing.TB
+}
+
+// New creates an Outgoing from the inline HOCON config, it is closed when
+// the test finishes.
+func New(t testing.TB, config string, opts ...bearychat.OutgoingOption) *Tester {
+	t.Helper()
+
+	incoming := NewIncomingServer()
+	t.Cleanup(incoming.Close)
+
+	conf := configuration.ParseString(strings.Replace(config, IncomingURL, incoming.URL, -1))
+
+	clock := NewClock(time.Now())
+
+	outgoing, err := bearychat.NewOutgoing(conf, append([]bearychat.OutgoingOption{bearychat.ClockOption(clock.Now)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { outgoing.Close() })
+
+	return &Tester{
+		Outgoing: outgoing,
+		Clock:    clock,
+		Incoming: incoming,
+		t:        t,
+	}
+}
+
+// Advance moves the fake clock forward.
+func (p *Tester) Advance(d time.Duration) {
+	p.Clock.Advance(d)
+}
+
+// Send sends text as user in channel, the trigger word and the token are
+// filled from the config, the token is taken from the drivers implementing
+// bearychat.TokenProvider, e.g. gogap-auth.
+func (p *Tester) Send(user, channel, text string) *Reply {
+	p.t.Helper()
+
+	return p.Request(&bearychat.OutgoingRequest{
+		Text:        text,
+		UserName:    user,
+		ChannelName: channel,
+	})
+}
+
+// Request handles req, an empty trigger word, token or timestamp is filled.
+func (p *Tester) Request(req *bearychat.OutgoingRequest) *Reply {
+	p.t.Helper()
+
+	if len(req.TriggerWord) == 0 {
+		req.TriggerWord = bearychat.DetectTriggerWord(p.Outgoing.Words(), req.Text)
+	}
+
+	if len(req.Token) == 0 {
+		req.Token = p.Outgoing.Token(req)
+	}
+
+	if req.Timestamp == 0 {
+		req.Timestamp = p.Clock.Now().Unix()
+	}
+
+	reply := &Reply{t: p.t, Request: req}
+	reply.Err = p.Outgoing.Handle(req, &reply.Message)
+
+	return reply
+}
+
+// Reply is the result of a handled request.
+type Reply struct {
+	Request *bearychat.OutgoingRequest
+	Message bearychat.Message
+	Err     error
+
+	t testing.TB
+}
+
+func (p *Reply) ExpectNoError() *Reply {
+	p.t.Helper()
+
+	if p.Err != nil {
+		p.t.Errorf("%q: unexpected error: %s", p.Request.Text, p.Err)
+	}
+
+	return p
+}
+
+// ExpectError expects an error of kind.
+func (p *Reply) ExpectError(kind bearychat.ErrorKind) *Reply {
+	p.t.Helper()
+
+	if p.Err == nil || p.Err == bearychat.ErrBreakOnly || p.Err == bearychat.ErrNoContent {
+		p.t.Errorf("%q: expected %s error, got: %v", p.Request.Text, kind, p.Err)
+	} else if bearychat.KindOf(p.Err) != kind {
+		p.t.Errorf("%q: expected %s error, got %s error: %s", p.Request.Text, kind, bearychat.KindOf(p.Err), p.Err)
+	}
+
+	return p
+}
+
+// ExpectErr expects err, e.g. bearychat.ErrBreakOnly.
+func (p *Reply) ExpectErr(err error) *Reply {
+	p.t.Helper()
+
+	if !errors.Is(p.Err, err) {
+		p.t.Errorf("%q: expected error %v, got: %v", p.Request.Text, err, p.Err)
+	}
+
+	return p
+}
+
+func (p *Reply) ExpectText(text string) *Reply {
+	p.t.Helper()
+
+	if p.Message.Text != text {
+		p.t.Errorf("%q: expected text %q, got %q (error: %v)", p.Request.Text, text, p.Message.Text, p.Err)
+	}
+
+	return p
+}
+
+func (p *Reply) ExpectContains(substr string) *Reply {
+	p.t.Helper()
+
+	if !strings.Contains(p.Message.Text, substr) {
+		p.t.Errorf("%q: expected text containing %q, got %q (error: %v)", p.Request.Text, substr, p.Message.Text, p.Err)
+	}
+
+	return p
+}
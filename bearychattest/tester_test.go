@@ -0,0 +1,91 @@
+package bearychattest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+
+	_ "github.com/gogap/bearychat/outgoing/triggers/auth"
+	_ "github.com/gogap/bearychat/outgoing/triggers/confirm"
+)
+
+type echo struct {
+	url string
+}
+
+func newEcho(word string, config *configuration.Config) (bearychat.Trigger, error) {
+	return &echo{url: config.GetString("notify-url")}, nil
+}
+
+// newTester creates a tester with test-echo registered for it only.
+func newTester(t *testing.T, config string) *Tester {
+	registry := bearychat.DefaultRegistry.Clone()
+	registry.Register("test-echo", newEcho)
+
+	return New(t, config, bearychat.RegistryOption(registry))
+}
+
+func (p *echo) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message) error {
+	msg.Text = strings.Join(req.Args(), " ")
+
+	if len(p.url) > 0 {
+		bearychat.NewIncomingClient().Send(p.url, &bearychat.Message{Text: "echoed " + msg.Text, Channel: req.ChannelName})
+	}
+
+	return nil
+}
+
+func TestTester(t *testing.T) {
+
+	tester := newTester(t, `{
+		deploy {
+			word = "!deploy"
+			drivers = [gogap-auth, gogap-confirm, test-echo]
+			gogap-auth.token = "secret"
+			gogap-confirm.prompt = "confirm"
+			test-echo.notify-url = "$INCOMING_URL/hook"
+		}
+	}`)
+
+	challenge := func() string {
+		reply := tester.Send("zeal", "ops", "!deploy web").ExpectErr(bearychat.ErrBreakOnly)
+		return strings.TrimPrefix(reply.Message.Text, "confirm: ")
+	}
+
+	tester.Send("zeal", "ops", "!deploy "+challenge()).ExpectNoError().ExpectText("web")
+
+	msgs := tester.Incoming.Messages()
+	if len(msgs) != 1 || msgs[0].Text != "echoed web" || msgs[0].Channel != "ops" {
+		t.Errorf("bad incoming messages: %v", msgs)
+	}
+
+	number := challenge()
+	tester.Advance(time.Minute)
+	tester.Send("zeal", "ops", "!deploy "+number).ExpectErr(bearychat.ErrBreakOnly).ExpectContains("confirm: ")
+
+	tester.Request(&bearychat.OutgoingRequest{Text: "!deploy web", Token: "bad", UserName: "zeal"}).ExpectError(bearychat.ErrorKindPermission)
+}
+
+func TestTesterClocks(t *testing.T) {
+
+	config := `{
+		deploy {
+			word = "!deploy"
+			drivers = [gogap-confirm, test-echo]
+			gogap-confirm.prompt = "confirm"
+		}
+	}`
+
+	first, second := newTester(t, config), newTester(t, config)
+
+	firstNumber := strings.TrimPrefix(first.Send("zeal", "ops", "!deploy web").Message.Text, "confirm: ")
+	secondNumber := strings.TrimPrefix(second.Send("zeal", "ops", "!deploy web").Message.Text, "confirm: ")
+
+	first.Advance(time.Minute)
+
+	first.Send("zeal", "ops", "!deploy "+firstNumber).ExpectErr(bearychat.ErrBreakOnly)
+	second.Send("zeal", "ops", "!deploy "+secondNumber).ExpectNoError().ExpectText("web")
+}
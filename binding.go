@@ -0,0 +1,44 @@
+package bearychat
+
+import (
+	"time"
+
+	"github.com/gogap/bearychat/internal/metrics"
+)
+
+type binding struct {
+	word     string
+	commands []string
+	drivers  []string
+	triggers []Trigger
+
+	limiter  *Limiter
+	dedupTTL time.Duration
+
+	errorTemplate *ErrorTemplate
+}
+
+func (p *binding) Handle(req *OutgoingRequest, msg *Message, entry *RequestLog) error {
+
+	release, err := p.limiter.Acquire(req)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	for i := 0; i < len(p.triggers); i++ {
+		start := time.Now()
+		err := p.triggers[i].Handle(req, msg)
+		entry.driver(p.drivers[i], start, err)
+
+		_, outcome := ReplyStatus(err)
+		metrics.ObserveDriver(p.drivers[i], outcome, start)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
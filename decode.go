@@ -0,0 +1,160 @@
+package bearychat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedMediaType = errors.New("unsupported content type")
+)
+
+var (
+	formFields = map[string]bool{
+		"token":        true,
+		"ts":           true,
+		"text":         true,
+		"trigger_word": true,
+		"subdomain":    true,
+		"channel_name": true,
+		"user_name":    true,
+		"user_id":      true,
+		"channel_id":   true,
+		"vchannel_id":  true,
+	}
+)
+
+// decodeOutgoingRequest decodes body by its content type, JSON (the default
+// when no content type is sent) and form encoded bodies are supported. In
+// strict mode unknown fields and missing token, trigger_word or text are
+// rejected.
+func decodeOutgoingRequest(contentType string, body []byte, strict bool) (*OutgoingRequest, error) {
+	mediaType := "application/json"
+	params := map[string]string{}
+
+	if len(contentType) > 0 {
+		var err error
+		mediaType, params, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, ErrUnsupportedMediaType
+		}
+	}
+
+	if charset, exist := params["charset"]; exist && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	var (
+		triggerReq *OutgoingRequest
+		err        error
+	)
+
+	switch mediaType {
+	case "application/json":
+		triggerReq, err = decodeJSON(body, strict)
+	case "application/x-www-form-urlencoded":
+		triggerReq, err = decodeForm(body, strict)
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strict {
+		var missing []string
+
+		if len(triggerReq.Token) == 0 {
+			missing = append(missing, "token")
+		}
+
+		if len(triggerReq.TriggerWord) == 0 {
+			missing = append(missing, "trigger_word")
+		}
+
+		if len(triggerReq.Text) == 0 {
+			missing = append(missing, "text")
+		}
+
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	return triggerReq, nil
+}
+
+func decodeJSON(body []byte, strict bool) (*OutgoingRequest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	triggerReq := &OutgoingRequest{}
+	if err := decoder.Decode(triggerReq); err != nil {
+		return nil, err
+	}
+
+	return triggerReq, nil
+}
+
+func decodeForm(body []byte, strict bool) (*OutgoingRequest, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if strict {
+		if err := checkFormFields(form); err != nil {
+			return nil, err
+		}
+	}
+
+	triggerReq := &OutgoingRequest{
+		Token:       form.Get("token"),
+		Text:        form.Get("text"),
+		TriggerWord: form.Get("trigger_word"),
+		Subdomain:   form.Get("subdomain"),
+		ChannelName: form.Get("channel_name"),
+		UserName:    form.Get("user_name"),
+		UserID:      form.Get("user_id"),
+		ChannelID:   form.Get("channel_id"),
+		VChannelID:  form.Get("vchannel_id"),
+	}
+
+	if ts := form.Get("ts"); len(ts) > 0 {
+		timestamp, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad ts: %s", ts)
+		}
+		triggerReq.Timestamp = timestamp
+	}
+
+	return triggerReq, nil
+}
+
+func checkFormFields(form url.Values) error {
+	var unknown []string
+	for field := range form {
+		if !formFields[field] {
+			unknown = append(unknown, field)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
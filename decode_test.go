@@ -0,0 +1,73 @@
+package bearychat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestDecodeHttpRequest(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		deploy {
+			word = "!ops"
+			commands = [deploy]
+			drivers = [test-annotator]
+		}
+	}`)
+
+	outgoing, _ := NewOutgoing(config, RegistryOption(testRegistry()))
+	strict, _ := NewOutgoing(config, RegistryOption(testRegistry()), StrictOption(true))
+
+	post := func(out *Outgoing, contentType, body string) (int, string) {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		if len(contentType) > 0 {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		rw := httptest.NewRecorder()
+		out.HandleHttpRequest(rw, req)
+
+		msg := Message{}
+		json.Unmarshal(rw.Body.Bytes(), &msg)
+
+		return rw.Code, msg.Text
+	}
+
+	jsonBody := `{"token":"t","text":"!ops deploy","trigger_word":"!ops","user_name":"zeal"}`
+	formBody := "token=t&text=%21ops+deploy&trigger_word=%21ops&user_name=zeal&ts=1500000000"
+
+	for _, c := range []struct {
+		out         *Outgoing
+		contentType string
+		body        string
+		status      int
+	}{
+		{outgoing, "", jsonBody, http.StatusOK},
+		{outgoing, "application/json; charset=UTF-8", jsonBody, http.StatusOK},
+		{outgoing, "application/x-www-form-urlencoded", formBody, http.StatusOK},
+		{outgoing, "application/json; charset=gbk", jsonBody, http.StatusUnsupportedMediaType},
+		{outgoing, "text/plain", jsonBody, http.StatusUnsupportedMediaType},
+		{outgoing, "application/json", "{bad", http.StatusBadRequest},
+		{outgoing, "application/x-www-form-urlencoded", "ts=abc", http.StatusBadRequest},
+		{outgoing, "application/json", `{"text":"!ops deploy","trigger_word":"!ops","extra":1}`, http.StatusOK},
+		{strict, "application/json", jsonBody, http.StatusOK},
+		{strict, "application/x-www-form-urlencoded", formBody, http.StatusOK},
+		{strict, "application/json", `{"token":"t","text":"!ops deploy","trigger_word":"!ops","extra":1}`, http.StatusBadRequest},
+		{strict, "application/json", `{"text":"!ops deploy","trigger_word":"!ops"}`, http.StatusBadRequest},
+		{strict, "application/x-www-form-urlencoded", formBody + "&extra=1", http.StatusBadRequest},
+	} {
+		status, text := post(c.out, c.contentType, c.body)
+		if status != c.status {
+			t.Errorf("%s %s: expected status %d, got %d", c.contentType, c.body, c.status, status)
+		}
+
+		if status == http.StatusOK && text != "secret output" {
+			t.Errorf("%s %s: bad reply: %s", c.contentType, c.body, text)
+		}
+	}
+}
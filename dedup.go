@@ -0,0 +1,148 @@
+package bearychat
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	dedupSweepInterval = time.Minute
+)
+
+var (
+	errDedupPanic = errors.New("duplicated request panicked")
+)
+
+type dedupEntry struct {
+	msg     Message
+	err     error
+	expires time.Time
+	done    chan struct{}
+}
+
+func (p *dedupEntry) expired(now time.Time) bool {
+	return !p.expires.IsZero() && now.After(p.expires)
+}
+
+// dedupCache remembers the result of handled requests, so a retried delivery
+// of the same outgoing request gets the first result back instead of running
+// the drivers again. Only successful results are kept, expired entries are
+// swept every minute once the cache is used.
+type dedupCache struct {
+	now      func() time.Time
+	entries  map[string]*dedupEntry
+	sweeping bool
+	closed   bool
+	stop     chan struct{}
+
+	sync.Mutex
+}
+
+func newDedupCache(now func() time.Time) *dedupCache {
+	return &dedupCache{
+		now:     now,
+		entries: make(map[string]*dedupEntry),
+		stop:    make(chan struct{}),
+	}
+}
+
+func dedupKey(req *OutgoingRequest) string {
+	h := sha1.New()
+
+	for _, v := range []string{req.Token, strconv.FormatInt(req.Timestamp, 10), req.UserName, req.ChannelName, req.Text} {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (p *dedupCache) Do(key string, ttl time.Duration, msg *Message, fn func(*Message) error) (err error) {
+	p.Lock()
+
+	if !p.sweeping && !p.closed {
+		p.sweeping = true
+		go p.sweepLoop(dedupSweepInterval)
+	}
+
+	if e, exist := p.entries[key]; exist && !e.expired(p.now()) {
+		p.Unlock()
+
+		<-e.done
+		*msg = e.msg
+
+		return e.err
+	}
+
+	e := &dedupEntry{done: make(chan struct{})}
+	p.entries[key] = e
+
+	p.Unlock()
+
+	completed := false
+
+	defer func() {
+		p.Lock()
+		if completed && err == nil {
+			e.msg = *msg
+			e.expires = p.now().Add(ttl)
+		} else {
+			// failures are not cached, so the next delivery runs again
+			e.err = err
+			if !completed {
+				e.err = errDedupPanic
+			}
+			delete(p.entries, key)
+		}
+		p.Unlock()
+
+		close(e.done)
+	}()
+
+	err = fn(msg)
+	completed = true
+
+	return
+}
+
+func (p *dedupCache) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.sweep()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *dedupCache) sweep() {
+	now := p.now()
+
+	p.Lock()
+	defer p.Unlock()
+
+	for k, e := range p.entries {
+		if e.expired(now) {
+			delete(p.entries, k)
+		}
+	}
+}
+
+// Close stops the sweeper.
+func (p *dedupCache) Close() {
+	p.Lock()
+	defer p.Unlock()
+
+	if !p.closed {
+		p.closed = true
+		close(p.stop)
+	}
+}
@@ -0,0 +1,106 @@
+package bearychat
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+type counter struct {
+	count int
+}
+
+func NewCounter(word string, config *configuration.Config) (Trigger, error) {
+	return &counter{}, nil
+}
+
+func (p *counter) Handle(req *OutgoingRequest, msg *Message) error {
+	p.count++
+	msg.Text = strconv.Itoa(p.count)
+	return nil
+}
+
+func TestDedupOutgoingRequest(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		deploy {
+			word = "!cmd"
+			commands = [deploy]
+			drivers = [test-counter]
+			dedup.ttl = 1m
+		}
+
+		status {
+			word = "!cmd"
+			commands = [status]
+			drivers = [test-counter]
+		}
+	}`)
+
+	outgoing, err := NewOutgoing(config, RegistryOption(testRegistry()))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	send := func(text string) string {
+		msg := Message{}
+		req := &OutgoingRequest{Token: "token", Timestamp: 1355517523, Text: text, TriggerWord: "!cmd", UserName: "zeal"}
+		if err := outgoing.Handle(req, &msg); err != nil {
+			t.Error(err)
+		}
+		return msg.Text
+	}
+
+	if first, second := send("!cmd deploy"), send("!cmd deploy"); first != "1" || second != "1" {
+		t.Errorf("duplicated delivery should get the cached message, got %s and %s", first, second)
+	}
+
+	if first, second := send("!cmd status"), send("!cmd status"); first != "1" || second != "2" {
+		t.Errorf("trigger without dedup should run every time, got %s and %s", first, second)
+	}
+}
+
+func TestDedupCacheFailures(t *testing.T) {
+
+	cache := newDedupCache(time.Now)
+	defer cache.Close()
+
+	calls := 0
+	fail := func(msg *Message) error {
+		calls++
+		return ErrBusy
+	}
+
+	cache.Do("key", time.Minute, &Message{}, fail)
+	cache.Do("key", time.Minute, &Message{}, fail)
+
+	if calls != 2 {
+		t.Errorf("errors should not be cached, handler called %d times", calls)
+	}
+
+	func() {
+		defer func() { recover() }()
+		cache.Do("panic", time.Minute, &Message{}, func(msg *Message) error { panic("boom") })
+	}()
+
+	if err := cache.Do("panic", time.Minute, &Message{}, func(msg *Message) error { return nil }); err != nil {
+		t.Errorf("entry of a panicked handler should be removed: %v", err)
+	}
+}
+
+func TestDedupCacheSweep(t *testing.T) {
+
+	cache := newDedupCache(time.Now)
+	defer cache.Close()
+
+	cache.Do("key", -time.Second, &Message{}, func(msg *Message) error { return nil })
+
+	cache.sweep()
+
+	if n := len(cache.entries); n != 0 {
+		t.Errorf("expired entries should be swept, %d left", n)
+	}
+}
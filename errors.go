@@ -0,0 +1,208 @@
+package bearychat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"text/template"
+
+	"github.com/go-akka/configuration"
+)
+
+const (
+	internalErrorMessage = "internal error"
+	defaultErrorText     = "{{.Message}}{{if .Reference}}, reference: {{.Reference}}{{end}}"
+)
+
+var (
+	defaultErrorTemplate = template.Must(template.New("error").Parse(defaultErrorText))
+)
+
+type ErrorKind int
+
+const (
+	ErrorKindInternal ErrorKind = iota
+	ErrorKindUser
+	ErrorKindPermission
+)
+
+func (p ErrorKind) String() string {
+	switch p {
+	case ErrorKindUser:
+		return "user"
+	case ErrorKindPermission:
+		return "permission denied"
+	}
+
+	return "internal"
+}
+
+// Error classifies the cause, only the message of user and permission errors
+// is shown in chat, internal errors are logged and replaced by a reference.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (p *Error) Error() string {
+	return p.Err.Error()
+}
+
+func (p *Error) Unwrap() error {
+	return p.Err
+}
+
+func UserError(err error) error {
+	return newError(ErrorKindUser, err)
+}
+
+func PermissionDenied(err error) error {
+	return newError(ErrorKindPermission, err)
+}
+
+func InternalError(err error) error {
+	return newError(ErrorKindInternal, err)
+}
+
+func newError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of err, errors which are not classified are internal.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	return ErrorKindInternal
+}
+
+// ErrorData is passed to error templates.
+type ErrorData struct {
+	Kind      string
+	Message   string
+	Reference string
+	User      string
+	Channel   string
+	Word      string
+}
+
+func newErrorData(req *OutgoingRequest, cause error) ErrorData {
+	data := ErrorData{
+		Kind:    KindOf(cause).String(),
+		Message: cause.Error(),
+	}
+
+	if req != nil {
+		data.User = req.UserName
+		data.Channel = req.ChannelName
+		data.Word = req.TriggerWord
+	}
+
+	if KindOf(cause) == ErrorKindInternal {
+		data.Reference = newReference()
+		if req != nil && len(req.ID) > 0 {
+			data.Reference = req.ID
+		}
+
+		data.Message = internalErrorMessage
+	}
+
+	return data
+}
+
+func newReference() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ErrorTemplate renders errors of a trigger into a message.
+//
+//	error {
+//	    text = "{{.Message}}"
+//	    attachment {
+//	        title = "{{.Kind}} error"
+//	        text  = "reference: {{.Reference}}"
+//	        color = "#e74c3c"
+//	    }
+//	}
+type ErrorTemplate struct {
+	text  *template.Template
+	title *template.Template
+	body  *template.Template
+	color string
+}
+
+func NewErrorTemplate(config *configuration.Config) (*ErrorTemplate, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var err error
+
+	tmpl := &ErrorTemplate{}
+
+	if tmpl.text, err = parseTemplate(config.GetString("text", defaultErrorText)); err != nil {
+		return nil, err
+	}
+
+	if attachment := config.GetConfig("attachment"); attachment != nil {
+		if tmpl.title, err = parseTemplate(attachment.GetString("title")); err != nil {
+			return nil, err
+		}
+
+		if tmpl.body, err = parseTemplate(attachment.GetString("text")); err != nil {
+			return nil, err
+		}
+
+		tmpl.color = attachment.GetString("color")
+	}
+
+	return tmpl, nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	if len(text) == 0 {
+		return nil, nil
+	}
+
+	return template.New("error").Parse(text)
+}
+
+func (p *ErrorTemplate) Render(data ErrorData) Message {
+	msg := Message{
+		Text: execTemplate(p.text, data),
+	}
+
+	if p.title != nil || p.body != nil || len(p.color) > 0 {
+		msg.Attachments = []Attachment{
+			{
+				Title: execTemplate(p.title, data),
+				Text:  execTemplate(p.body, data),
+				Color: p.color,
+			},
+		}
+	}
+
+	return msg
+}
+
+func execTemplate(tmpl *template.Template, data ErrorData) string {
+	if tmpl == nil {
+		return ""
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return data.Message
+	}
+
+	return buf.String()
+}
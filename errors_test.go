@@ -0,0 +1,116 @@
+package bearychat
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+type failure struct{}
+
+func newFailure(word string, config *configuration.Config) (Trigger, error) {
+	return &failure{}, nil
+}
+
+func (p *failure) Handle(req *OutgoingRequest, msg *Message) error {
+	switch req.Args()[0] {
+	case "denied":
+		return PermissionDenied(errors.New("you shall not pass"))
+	}
+	return errors.New("exit status 1: secret stderr")
+}
+
+func TestErrorTemplate(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		plain {
+			word = "!fail"
+			commands = [plain]
+			drivers = [test-failure]
+		}
+
+		templated {
+			word = "!fail"
+			commands = [templated]
+			drivers = [test-failure]
+
+			error {
+				text = "{{.User}}: {{.Message}}"
+				attachment {
+					title = "{{.Kind}}"
+					color = "#e74c3c"
+				}
+			}
+		}
+	}`)
+
+	outgoing, err := NewOutgoing(config, RegistryOption(testRegistry()))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	send := func(text string) Message {
+		req := &OutgoingRequest{Text: text, TriggerWord: "!fail", UserName: "zeal"}
+		msg := Message{}
+		if err := outgoing.Handle(req, &msg); err != nil {
+			msg = outgoing.renderError(req, err)
+		}
+		return msg
+	}
+
+	if msg := send("!fail plain denied"); msg.Text != "you shall not pass" {
+		t.Errorf("permission errors should be shown as is, got: %s", msg.Text)
+	}
+
+	if msg := send("!fail plain internal"); strings.Contains(msg.Text, "secret") || !strings.Contains(msg.Text, "reference") {
+		t.Errorf("internal errors should be hidden behind a reference, got: %s", msg.Text)
+	}
+
+	msg := send("!fail templated denied")
+	if msg.Text != "zeal: you shall not pass" {
+		t.Errorf("bad templated error text: %s", msg.Text)
+	}
+
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Title != "permission denied" || msg.Attachments[0].Color != "#e74c3c" {
+		t.Errorf("bad templated error attachment: %v", msg.Attachments)
+	}
+}
+
+func TestInternalErrorLog(t *testing.T) {
+
+	buf := bytes.NewBuffer(nil)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	leak := func(req *OutgoingRequest, msg *Message) error {
+		return errors.New("bad request: token=" + req.Token)
+	}
+
+	redactor, _ := NewRedactor([]string{"token"}, nil)
+	logged := bytes.NewBuffer(nil)
+
+	withLogger, _ := NewOutgoing(nil, LoggerOption(NewJSONLogger(logged, redactor)))
+	withLogger.HandleFunc("!leak", nil, leak)
+
+	withoutLogger, _ := NewOutgoing(nil)
+	withoutLogger.HandleFunc("!leak", nil, leak)
+
+	withLogger.Reply(&OutgoingRequest{Text: "!leak", TriggerWord: "!leak", Token: "s3cret"})
+
+	if buf.Len() > 0 || strings.Contains(logged.String(), "s3cret") || !strings.Contains(logged.String(), "token=******") {
+		t.Errorf("internal errors should only be written by the logger, redacted: %q %q", buf, logged)
+	}
+
+	req := &OutgoingRequest{Text: "!leak", TriggerWord: "!leak", Token: "s3cret"}
+	_, msg := withoutLogger.Reply(req)
+
+	if out := buf.String(); strings.Contains(out, "s3cret") || !strings.Contains(out, "token=******") || !strings.Contains(out, req.ID) || !strings.Contains(msg.Text, req.ID) {
+		t.Errorf("internal errors should be printed redacted with the reference: %q %q", out, msg.Text)
+	}
+}
@@ -0,0 +1,177 @@
+package bearychat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestHandleFunc(t *testing.T) {
+
+	outgoing, err := NewOutgoing(configuration.ParseString(`{
+		deploy {
+			word = "!ops"
+			commands = [deploy]
+			drivers = [test-annotator]
+		}
+	}`), RegistryOption(testRegistry()))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	echo := func(req *OutgoingRequest, msg *Message) error {
+		msg.Text = strings.Join(append(req.Commands, req.Args()...), " ")
+		return nil
+	}
+
+	for _, commands := range [][]string{{"status"}, {"status", "db"}, nil} {
+		if err = outgoing.HandleFunc("!ops", commands, echo); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	if err = outgoing.HandleFunc("!ops", []string{"deploy"}, echo); err == nil {
+		t.Error("binding a bound command again should fail")
+	}
+
+	var calls []string
+
+	outgoing.Use(func(next TriggerHandleFunc) TriggerHandleFunc {
+		return func(req *OutgoingRequest, msg *Message) error {
+			calls = append(calls, req.UserName)
+			if req.UserName == "guest" {
+				return PermissionDenied(errors.New("guests are not allowed"))
+			}
+			return next(req, msg)
+		}
+	})
+
+	for text, expected := range map[string]string{
+		"!ops deploy web":    "secret output",
+		"!ops status web":    "status web",
+		"!ops status db web": "status db web",
+		"!ops uptime":        "uptime",
+	} {
+		msg := Message{}
+		if err = outgoing.Handle(&OutgoingRequest{Text: text, TriggerWord: "!ops", UserName: "zeal"}, &msg); err != nil || msg.Text != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", text, expected, msg.Text, err)
+		}
+	}
+
+	err = outgoing.Handle(&OutgoingRequest{Text: "!ops status", TriggerWord: "!ops", UserName: "guest"}, &Message{})
+	if KindOf(err) != ErrorKindPermission {
+		t.Errorf("middleware should deny guests, got: %v", err)
+	}
+
+	if len(calls) != 5 {
+		t.Errorf("middleware should wrap config and code bound triggers, got %d calls", len(calls))
+	}
+}
+
+func TestHandleFuncNested(t *testing.T) {
+
+	echo := func(text string) TriggerHandleFunc {
+		return func(req *OutgoingRequest, msg *Message) error {
+			msg.Text = text + " " + strings.Join(req.Args(), " ")
+			return nil
+		}
+	}
+
+	for _, order := range [][][]string{{{"deploy"}, {"deploy", "web"}}, {{"deploy", "web"}, {"deploy"}}} {
+		outgoing, _ := NewOutgoing(nil)
+
+		for _, commands := range order {
+			if err := outgoing.HandleFunc("!ops", commands, echo(strings.Join(commands, "-"))); err != nil {
+				t.Errorf("%v: %s", order, err)
+			}
+		}
+
+		for text, expected := range map[string]string{
+			"!ops deploy api":     "deploy api",
+			"!ops deploy web now": "deploy-web now",
+		} {
+			msg := Message{}
+			if err := outgoing.Handle(&OutgoingRequest{Text: text, TriggerWord: "!ops"}, &msg); err != nil || msg.Text != expected {
+				t.Errorf("%v %s: expected %q, got %q (%v)", order, text, expected, msg.Text, err)
+			}
+		}
+	}
+}
+
+func TestHandleFuncOptions(t *testing.T) {
+
+	outgoing, _ := NewOutgoing(nil)
+
+	tmpl, err := NewErrorTemplate(configuration.ParseString(`{ text = "deploy failed: {{.Message}}" }`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	calls := 0
+
+	err = outgoing.HandleFunc("!deploy", nil, func(req *OutgoingRequest, msg *Message) error {
+		calls++
+		if req.Args()[0] == "bad" {
+			return UserError(errors.New("unknown service"))
+		}
+		msg.Text = "deployed"
+		return nil
+	}, BindDedup(time.Minute), BindErrorTemplate(tmpl))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, msg := outgoing.Reply(&OutgoingRequest{Text: "!deploy web", TriggerWord: "!deploy", Token: "t", Timestamp: 1}); msg.Text != "deployed" {
+			t.Errorf("expected deployed, got %q", msg.Text)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("the duplicated request should not be handled again, got %d calls", calls)
+	}
+
+	if _, msg := outgoing.Reply(&OutgoingRequest{Text: "!deploy bad", TriggerWord: "!deploy"}); msg.Text != "deploy failed: unknown service" {
+		t.Errorf("the error should be rendered by the template of the binding, got %q", msg.Text)
+	}
+}
+
+func TestHandleFuncWhileServing(t *testing.T) {
+
+	outgoing, _ := NewOutgoing(nil)
+
+	ok := func(req *OutgoingRequest, msg *Message) error { return nil }
+
+	outgoing.HandleFunc("!ops", nil, ok)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				outgoing.Reply(&OutgoingRequest{Text: "!ops", TriggerWord: "!ops"})
+				outgoing.Words()
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		outgoing.HandleFunc("!ops", []string{fmt.Sprintf("cmd%d", i)}, ok)
+		outgoing.Use(func(next TriggerHandleFunc) TriggerHandleFunc { return next })
+	}
+
+	wg.Wait()
+}
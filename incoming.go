@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/gogap/bearychat/internal/metrics"
 )
 
 type IncomingClient struct {
 	client *http.Client
+
+	retries       int
+	retryInterval time.Duration
 }
 
 type ClientOption func(*IncomingClient)
@@ -36,13 +43,44 @@ func (p *IncomingClient) Send(url string, msg *Message) (resp *IncomingResponse,
 		return
 	}
 
+	for i := 0; ; i++ {
+		var retry bool
+
+		resp, retry, err = p.send(url, body)
+		if !retry || i >= p.retries {
+			break
+		}
+
+		metrics.IncomingRetries.Inc()
+		time.Sleep(p.retryInterval)
+	}
+
+	if err != nil || resp.Code != 0 {
+		metrics.IncomingSends.WithLabelValues("failure").Inc()
+	} else {
+		metrics.IncomingSends.WithLabelValues("success").Inc()
+	}
+
+	return
+}
+
+// send posts the message once, retry is true only when the webhook surely
+// did not deliver the message: the connection could not be established or
+// the webhook answered 429 or 503. Responses of other statuses are decoded
+// as before, so callers still get the error code of the webhook.
+func (p *IncomingClient) send(url string, body []byte) (resp *IncomingResponse, retry bool, err error) {
+
 	httpResp, err := p.client.Post(url, "application/json", bytes.NewBuffer(body))
 	if err != nil {
+		var opErr *net.OpError
+		retry = errors.As(err, &opErr) && opErr.Op == "dial"
 		return
 	}
 
 	defer httpResp.Body.Close()
 
+	retry = httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode == http.StatusServiceUnavailable
+
 	r := IncomingResponse{}
 
 	decoder := json.NewDecoder(httpResp.Body)
@@ -51,6 +89,9 @@ func (p *IncomingClient) Send(url string, msg *Message) (resp *IncomingResponse,
 	err = decoder.Decode(&r)
 
 	if err != nil {
+		if httpResp.StatusCode >= 500 {
+			err = fmt.Errorf("incoming webhook responded with status %d", httpResp.StatusCode)
+		}
 		return
 	}
 
@@ -71,6 +112,15 @@ func TransportOption(transport *http.Transport) ClientOption {
 	}
 }
 
+// RetryOption retries sending when the message was surely not delivered:
+// the connection failed or the webhook responded 429 or 503.
+func RetryOption(retries int, interval time.Duration) ClientOption {
+	return func(c *IncomingClient) {
+		c.retries = retries
+		c.retryInterval = interval
+	}
+}
+
 func TimeoutOption(timeout time.Duration) ClientOption {
 	return func(c *IncomingClient) {
 		c.client.Timeout = timeout
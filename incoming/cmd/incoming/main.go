@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gogap/bearychat/incoming/fake"
+	"github.com/urfave/cli"
+)
+
+func main() {
+
+	app := cli.NewApp()
+	app.Version = "1.0.0"
+	app.Name = "incoming"
+	app.Usage = "tools for bearychat incoming webhooks"
+	app.HelpName = "incoming"
+
+	app.Commands = []cli.Command{
+		{
+			Name:   "fake",
+			Usage:  "run a fake incoming webhook server for local development",
+			Action: cmdFake,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "listen",
+					Value: "127.0.0.1:8081",
+					Usage: "listen address",
+				},
+				cli.StringSliceFlag{
+					Name:  "hook",
+					Usage: "accepted hook path, may be repeated, every path is accepted by default",
+				},
+				cli.DurationFlag{
+					Name:  "latency",
+					Usage: "delay of every hook response",
+				},
+				cli.Float64Flag{
+					Name:  "failure-rate",
+					Usage: "part of the messages answered with a server error, 0 to 1",
+				},
+			},
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func cmdFake(c *cli.Context) error {
+	server := fake.NewServer(
+		fake.HooksOption(c.StringSlice("hook")...),
+		fake.LatencyOption(c.Duration("latency")),
+		fake.FailureRateOption(c.Float64("failure-rate")),
+	)
+
+	listen := c.String("listen")
+
+	fmt.Printf("fake incoming webhook listening on http://%s, messages on http://%s/\n", listen, listen)
+
+	return http.ListenAndServe(listen, server)
+}
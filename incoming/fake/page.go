@@ -0,0 +1,59 @@
+package fake
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="2">
+<title>fake incoming webhook</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #333; }
+.msg { border-bottom: 1px solid #eee; padding: .6em 0; }
+.meta { color: #999; font-size: .85em; }
+.error { color: #c0392b; }
+.text { white-space: pre-wrap; }
+.attachment { border-left: 4px solid #ddd; margin: .4em 0; padding-left: .6em; }
+</style>
+</head>
+<body>
+<h1>fake incoming webhook</h1>
+{{range .}}
+<div class="msg">
+  <div class="meta">#{{.ID}} {{.Time.Format "15:04:05"}} {{.Hook}} {{.Status}}{{with .Message}}{{if .Channel}} #{{.Channel}}{{end}}{{if .User}} @{{.User}}{{end}}{{end}}</div>
+  {{if .Response.Error}}<div class="error">{{.Response.Error}}</div>{{end}}
+  {{with .Message}}
+  <div class="text">{{.Text}}</div>
+  {{range .Attachments}}
+  <div class="attachment"{{if .Color}} style="border-color: {{.Color}}"{{end}}>
+    {{if .Title}}<strong>{{.Title}}</strong>{{end}}
+    {{if .Text}}<div class="text">{{.Text}}</div>{{end}}
+    {{range .Images}}<div><img src="{{.URL}}" height="120"></div>{{end}}
+  </div>
+  {{end}}
+  {{else}}
+  <div class="text">{{.Body}}</div>
+  {{end}}
+</div>
+{{else}}
+<p>no messages yet</p>
+{{end}}
+</body>
+</html>
+`))
+
+func (p *Server) page(rw http.ResponseWriter) {
+	received := p.Received()
+
+	// newest first
+	for i, j := 0, len(received)-1; i < j; i, j = i+1, j-1 {
+		received[i], received[j] = received[j], received[i]
+	}
+
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	pageTemplate.Execute(rw, received)
+}
@@ -0,0 +1,270 @@
+// Package fake is a fake BearyChat incoming webhook for local development
+// and tests, it accepts messages on its hook paths, shows them on a web page
+// at / and serves them as JSON at /api/messages.
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogap/bearychat"
+)
+
+const (
+	CodeOK          = 0
+	CodeBadRequest  = 1
+	CodeNotFound    = 2
+	CodeServerError = 3
+)
+
+var (
+	colorExpr = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// Received is a message posted to a hook together with the response.
+type Received struct {
+	ID       int                        `json:"id"`
+	Time     time.Time                  `json:"time"`
+	Hook     string                     `json:"hook"`
+	Message  *bearychat.Message         `json:"message,omitempty"`
+	Body     string                     `json:"body,omitempty"`
+	Status   int                        `json:"status"`
+	Response bearychat.IncomingResponse `json:"response"`
+}
+
+type Option func(*Server)
+
+// HooksOption limits the accepted hook paths, by default every path not
+// used by the page and the api is a hook.
+func HooksOption(paths ...string) Option {
+	return func(s *Server) {
+		for _, path := range paths {
+			s.hooks["/"+strings.TrimPrefix(path, "/")] = true
+		}
+	}
+}
+
+// LatencyOption delays every response of the hooks.
+func LatencyOption(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// FailureRateOption answers the given part of the messages, 0 to 1, with a
+// server error.
+func FailureRateOption(rate float64) Option {
+	return func(s *Server) {
+		s.failureRate = rate
+	}
+}
+
+// HistoryOption sets how many received messages are kept, default 1000.
+func HistoryOption(size int) Option {
+	return func(s *Server) {
+		s.history = size
+	}
+}
+
+type Server struct {
+	hooks       map[string]bool
+	latency     time.Duration
+	failureRate float64
+	history     int
+
+	failNext int
+	nextID   int
+	received []*Received
+
+	sync.Mutex
+}
+
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		hooks:   make(map[string]bool),
+		history: 1000,
+	}
+
+	for i := 0; i < len(opts); i++ {
+		opts[i](s)
+	}
+
+	return s
+}
+
+// FailNext answers the next n messages with 503, a server error the clients
+// may retry.
+func (p *Server) FailNext(n int) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.failNext = n
+}
+
+// Received returns the received messages, oldest first.
+func (p *Server) Received() []*Received {
+	p.Lock()
+	defer p.Unlock()
+
+	return append([]*Received(nil), p.received...)
+}
+
+// Messages returns the accepted messages, oldest first.
+func (p *Server) Messages() []bearychat.Message {
+	var msgs []bearychat.Message
+
+	for _, r := range p.Received() {
+		if r.Response.Code == CodeOK {
+			msgs = append(msgs, *r.Message)
+		}
+	}
+
+	return msgs
+}
+
+func (p *Server) Reset() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.received = nil
+}
+
+func (p *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.URL.Path == "/" && req.Method == "GET":
+		p.page(rw)
+	case req.URL.Path == "/api/messages":
+		p.api(rw, req)
+	case req.URL.Path == "/api/fail" && req.Method == "POST":
+		n, err := strconv.Atoi(req.URL.Query().Get("n"))
+		if err != nil {
+			n = 1
+		}
+		p.FailNext(n)
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		p.hook(rw, req)
+	}
+}
+
+func (p *Server) api(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET":
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(p.Received())
+	case "DELETE":
+		p.Reset()
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (p *Server) hook(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if p.latency > 0 {
+		time.Sleep(p.latency)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.ReadFrom(req.Body)
+
+	r := &Received{
+		Time: time.Now(),
+		Hook: req.URL.Path,
+		Body: buf.String(),
+	}
+
+	r.Status, r.Response = p.respond(req.URL.Path, buf.Bytes(), r)
+
+	p.Lock()
+	p.nextID++
+	r.ID = p.nextID
+	p.received = append(p.received, r)
+	if len(p.received) > p.history {
+		p.received = p.received[len(p.received)-p.history:]
+	}
+	p.Unlock()
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(r.Status)
+	json.NewEncoder(rw).Encode(r.Response)
+}
+
+func (p *Server) respond(hook string, body []byte, r *Received) (int, bearychat.IncomingResponse) {
+	if len(p.hooks) > 0 && !p.hooks[hook] {
+		return http.StatusNotFound, bearychat.IncomingResponse{Code: CodeNotFound, Error: "hook not found"}
+	}
+
+	if p.fail() {
+		return http.StatusServiceUnavailable, bearychat.IncomingResponse{Code: CodeServerError, Error: "injected failure"}
+	}
+
+	msg, err := decodeMessage(body)
+	if err != nil {
+		return http.StatusBadRequest, bearychat.IncomingResponse{Code: CodeBadRequest, Error: err.Error()}
+	}
+
+	r.Message = msg
+	r.Body = ""
+
+	return http.StatusOK, bearychat.IncomingResponse{Code: CodeOK}
+}
+
+func (p *Server) fail() bool {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.failNext > 0 {
+		p.failNext--
+		return true
+	}
+
+	return p.failureRate > 0 && rand.Float64() < p.failureRate
+}
+
+// decodeMessage decodes and validates a message like the incoming webhook.
+func decodeMessage(body []byte) (*bearychat.Message, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	msg := &bearychat.Message{}
+	if err := decoder.Decode(msg); err != nil {
+		return nil, fmt.Errorf("bad message: %s", err.Error())
+	}
+
+	if len(strings.TrimSpace(msg.Text)) == 0 {
+		return nil, errors.New("text is required")
+	}
+
+	for i, attachment := range msg.Attachments {
+		if len(attachment.Title) == 0 && len(attachment.Text) == 0 && len(attachment.Images) == 0 {
+			return nil, fmt.Errorf("attachments[%d]: one of title, text or images is required", i)
+		}
+
+		if len(attachment.Color) > 0 && !colorExpr.MatchString(attachment.Color) {
+			return nil, fmt.Errorf("attachments[%d]: bad color %s, expected #rrggbb", i, attachment.Color)
+		}
+
+		for j, image := range attachment.Images {
+			if !strings.HasPrefix(image.URL, "http://") && !strings.HasPrefix(image.URL, "https://") {
+				return nil, fmt.Errorf("attachments[%d].images[%d]: bad url %q", i, j, image.URL)
+			}
+		}
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,47 @@
+package fake
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogap/bearychat"
+)
+
+func TestServer(t *testing.T) {
+
+	server := NewServer(HooksOption("/hook"))
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := bearychat.NewIncomingClient()
+
+	resp, err := client.Send(ts.URL+"/hook", &bearychat.Message{Text: "hello", Channel: "ops"})
+	if err != nil || resp.Err() != nil {
+		t.Errorf("message should be accepted: %v %v", err, resp)
+	}
+
+	resp, err = client.Send(ts.URL+"/hook", &bearychat.Message{Text: "hello", Attachments: []bearychat.Attachment{{Title: "t", Color: "red"}}})
+	if err != nil || resp.Code != CodeBadRequest {
+		t.Errorf("bad color should be rejected: %v %v", err, resp)
+	}
+
+	resp, err = client.Send(ts.URL+"/other", &bearychat.Message{Text: "hello"})
+	if err != nil || resp.Code != CodeNotFound {
+		t.Errorf("unknown hook should be rejected: %v %v", err, resp)
+	}
+
+	server.FailNext(1)
+
+	if resp, err = client.Send(ts.URL+"/hook", &bearychat.Message{Text: "hello"}); err != nil || resp.Code != CodeServerError {
+		t.Errorf("injected failure should be a server error: %v %v", err, resp)
+	}
+
+	if msgs := server.Messages(); len(msgs) != 1 || msgs[0].Text != "hello" || msgs[0].Channel != "ops" {
+		t.Errorf("bad accepted messages: %v", msgs)
+	}
+
+	if received := server.Received(); len(received) != 4 {
+		t.Errorf("all messages should be listed, got %d", len(received))
+	}
+}
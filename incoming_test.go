@@ -0,0 +1,55 @@
+package bearychat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogap/bearychat/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestIncomingClientRetry(t *testing.T) {
+
+	statuses := []int{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+
+		rw.WriteHeader(status)
+
+		if status == http.StatusOK {
+			rw.Write([]byte(`{"code":0}`))
+		} else {
+			rw.Write([]byte(`{"code":1,"error":"failure"}`))
+		}
+	}))
+	defer ts.Close()
+
+	client := NewIncomingClient(RetryOption(2, 0))
+
+	retries := testutil.ToFloat64(metrics.IncomingRetries)
+
+	statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+
+	if resp, err := client.Send(ts.URL, &Message{Text: "hello"}); err != nil || resp.Code != 0 {
+		t.Errorf("message should be delivered after retries: %v %v", err, resp)
+	}
+
+	if n := testutil.ToFloat64(metrics.IncomingRetries) - retries; n != 2 {
+		t.Errorf("expected 2 retries, got %v", n)
+	}
+
+	statuses = []int{http.StatusInternalServerError}
+
+	if resp, err := client.Send(ts.URL, &Message{Text: "hello"}); err != nil || resp.Code != 1 {
+		t.Errorf("500 should not be retried and the response should be returned: %v %v", err, resp)
+	}
+
+	if len(statuses) != 0 {
+		t.Error("500 should be sent once")
+	}
+}
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-akka/configuration"
+)
+
+// ByteSize reads a byte size like "64kB" or a plain number of bytes at path,
+// defaultSize is returned when the path is not set. Unlike GetByteSize it
+// returns an error instead of panicking on a bad value.
+func ByteSize(config *configuration.Config, path string, defaultSize int64) (size int64, err error) {
+	if config == nil || !config.HasPath(path) {
+		return defaultSize, nil
+	}
+
+	str := strings.TrimSpace(config.GetString(path))
+
+	if size, err = strconv.ParseInt(str, 10, 64); err == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			size, err = 0, fmt.Errorf("bad byte size of %s: %q", path, str)
+		}
+	}()
+
+	return config.GetByteSize(path).Int64(), nil
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestByteSize(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		plain = 1024
+		unit  = 64kB
+		bad   = "64 apples"
+	}`)
+
+	cases := []struct {
+		path string
+		size int64
+		ok   bool
+	}{
+		{"plain", 1024, true},
+		{"unit", 64000, true},
+		{"unset", 7, true},
+		{"bad", 0, false},
+	}
+
+	for _, c := range cases {
+		size, err := ByteSize(config, c.path, 7)
+		if size != c.size || (err == nil) != c.ok {
+			t.Errorf("%s: expected %d ok=%v, got %d %v", c.path, c.size, c.ok, size, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Label values are taken from the configured triggers and drivers only, so
+// the cardinality stays bounded whatever the users send.
+var (
+	Requests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bearychat",
+			Subsystem: "outgoing",
+			Name:      "requests_total",
+			Help:      "Outgoing requests handled, by service, team, trigger word, command and outcome.",
+		},
+		[]string{"service", "team", "word", "command", "outcome"},
+	)
+
+	RequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "bearychat",
+			Subsystem: "outgoing",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of handling outgoing requests.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 4, 8),
+		},
+		[]string{"service", "team", "word"},
+	)
+
+	InFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "bearychat",
+			Subsystem: "outgoing",
+			Name:      "requests_in_flight",
+			Help:      "Outgoing requests being handled.",
+		},
+	)
+
+	DriverDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "bearychat",
+			Subsystem: "outgoing",
+			Name:      "driver_duration_seconds",
+			Help:      "Duration of trigger drivers.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 9),
+		},
+		[]string{"driver", "outcome"},
+	)
+
+	Challenges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bearychat",
+			Subsystem: "outgoing",
+			Name:      "confirm_challenges_total",
+			Help:      "Confirm challenges, by driver and outcome.",
+		},
+		[]string{"driver", "outcome"},
+	)
+
+	IncomingSends = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bearychat",
+			Subsystem: "incoming",
+			Name:      "sends_total",
+			Help:      "Messages sent through incoming webhooks, by result.",
+		},
+		[]string{"result"},
+	)
+
+	IncomingRetries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "bearychat",
+			Subsystem: "incoming",
+			Name:      "retries_total",
+			Help:      "Retries of sending messages through incoming webhooks.",
+		},
+	)
+)
+
+// Register registers the collectors to reg, nothing is collected into a
+// registry until Register is called for it.
+func Register(reg prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		Requests,
+		RequestDuration,
+		InFlight,
+		DriverDuration,
+		Challenges,
+		IncomingSends,
+		IncomingRetries,
+	}
+
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ObserveDriver(name, outcome string, start time.Time) {
+	DriverDuration.WithLabelValues(name, outcome).Observe(time.Since(start).Seconds())
+}
+
+func Challenge(driver, outcome string) {
+	Challenges.WithLabelValues(driver, outcome).Inc()
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegister(t *testing.T) {
+
+	reg := prometheus.NewRegistry()
+
+	if err := Register(reg); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := Register(reg); err == nil {
+		t.Error("registering twice should fail")
+	}
+
+	challenges := testutil.ToFloat64(Challenges.WithLabelValues("gogap-confirm", "confirmed"))
+
+	Challenge("gogap-confirm", "confirmed")
+
+	if n := testutil.ToFloat64(Challenges.WithLabelValues("gogap-confirm", "confirmed")) - challenges; n != 1 {
+		t.Errorf("expected 1 confirmed challenge, got %v", n)
+	}
+
+	if n, err := testutil.GatherAndCount(reg, "bearychat_outgoing_confirm_challenges_total"); err != nil || n != 1 {
+		t.Errorf("challenges should be gathered from the registry: %d %v", n, err)
+	}
+}
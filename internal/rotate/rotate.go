@@ -0,0 +1,124 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File is an append only file which is rotated once it grows over maxSize,
+// rotated files are renamed to name.1, name.2, ... and at most maxBackups
+// of them are kept.
+type File struct {
+	name       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+
+	sync.Mutex
+}
+
+func Open(name string, maxSize int64, maxBackups int) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+
+	f := &File{
+		name:       name,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (p *File) open() error {
+	file, err := os.OpenFile(p.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	p.file = file
+	p.size = fi.Size()
+
+	return nil
+}
+
+// Write appends b to the file, a failed rotation does not lose b: the file
+// is reopened and b is appended to it, the rotation is retried by the next
+// Write.
+func (p *File) Write(b []byte) (n int, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.maxSize > 0 && p.size > 0 && p.size+int64(len(b)) > p.maxSize {
+		p.rotate()
+	}
+
+	if p.file == nil {
+		if err = p.open(); err != nil {
+			return
+		}
+	}
+
+	n, err = p.file.Write(b)
+	p.size += int64(n)
+
+	return
+}
+
+func (p *File) rotate() error {
+	err := p.file.Close()
+	p.file = nil
+
+	if err != nil {
+		return err
+	}
+
+	if p.maxBackups <= 0 {
+		os.Remove(p.name)
+	} else {
+		os.Remove(backupName(p.name, p.maxBackups))
+
+		for i := p.maxBackups - 1; i >= 1; i-- {
+			os.Rename(backupName(p.name, i), backupName(p.name, i+1))
+		}
+
+		if err = os.Rename(p.name, backupName(p.name, 1)); err != nil {
+			return err
+		}
+	}
+
+	return p.open()
+}
+
+func (p *File) Close() error {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.file == nil {
+		return nil
+	}
+
+	err := p.file.Close()
+	p.file = nil
+
+	return err
+}
+
+func backupName(name string, i int) string {
+	return fmt.Sprintf("%s.%d", name, i)
+}
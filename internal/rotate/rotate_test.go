@@ -0,0 +1,100 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "audit.jsonl")
+
+	f, err := Open(name, 10, 2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, line := range []string{"11111111\n", "22222222\n", "33333333\n", "44444444\n"} {
+		if _, err = f.Write([]byte(line)); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	f.Close()
+
+	for file, expected := range map[string]string{
+		name:        "44444444\n",
+		name + ".1": "33333333\n",
+		name + ".2": "22222222\n",
+	} {
+		data, err := ioutil.ReadFile(file)
+		if err != nil || string(data) != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", file, expected, data, err)
+		}
+	}
+
+	if _, err = os.Stat(name + ".3"); !os.IsNotExist(err) {
+		t.Error("only 2 backups should be kept")
+	}
+}
+
+func TestRotateFailure(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "rotate")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "audit.jsonl")
+
+	// a non-empty directory in place of the backup makes the rotation fail
+	if err = os.MkdirAll(filepath.Join(name+".1", "busy"), 0755); err != nil {
+		t.Error(err)
+		return
+	}
+
+	f, err := Open(name, 10, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, line := range []string{"11111111\n", "22222222\n"} {
+		if _, err = f.Write([]byte(line)); err != nil {
+			t.Errorf("write should go on after a failed rotation: %v", err)
+			return
+		}
+	}
+
+	os.RemoveAll(name + ".1")
+
+	if _, err = f.Write([]byte("33333333\n")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	f.Close()
+
+	for file, expected := range map[string]string{
+		name:        "33333333\n",
+		name + ".1": "11111111\n22222222\n",
+	} {
+		data, err := ioutil.ReadFile(file)
+		if err != nil || string(data) != expected {
+			t.Errorf("%s: expected %q, got %q (%v)", file, expected, data, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package bearychat
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+const (
+	defaultLimiterQueue = 10
+)
+
+var (
+	ErrBusy         = UserError(errors.New("busy, please try again later"))
+	ErrQueueTimeout = UserError(errors.New("timed out while waiting in queue, please try again later"))
+)
+
+// Limiter bounds the parallel executions of a trigger or a command, requests
+// over the limit wait in a FIFO queue or are rejected with ErrBusy.
+//
+//	concurrency {
+//	    max        = 1
+//	    queue      = 5       // default 10, must be positive in wait mode
+//	    mode       = wait    // wait or reject
+//	    timeout    = 5m      // max time to wait in queue
+//	    notify-url = "..."   // incoming webhook for "queued at position N" replies
+//	    notify-retries = 2
+//	}
+type Limiter struct {
+	max     int
+	queue   int
+	reject  bool
+	timeout time.Duration
+
+	notifyURL string
+	client    *IncomingClient
+
+	running int
+	waiters []chan struct{}
+
+	sync.Mutex
+}
+
+func NewLimiter(config *configuration.Config) (*Limiter, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	max := int(config.GetInt32("max", 0))
+	if max <= 0 {
+		return nil, nil
+	}
+
+	limiter := &Limiter{
+		max:       max,
+		queue:     int(config.GetInt32("queue", defaultLimiterQueue)),
+		timeout:   config.GetTimeDuration("timeout", 0),
+		notifyURL: config.GetString("notify-url"),
+	}
+
+	switch mode := config.GetString("mode", "wait"); mode {
+	case "wait":
+	case "reject":
+		limiter.reject = true
+	default:
+		return nil, fmt.Errorf("unknown concurrency mode: %s", mode)
+	}
+
+	if !limiter.reject && limiter.queue <= 0 {
+		return nil, errors.New("concurrency queue must be positive in wait mode, use mode = reject to disable queueing")
+	}
+
+	if len(limiter.notifyURL) > 0 {
+		limiter.client = NewIncomingClient(
+			TimeoutOption(10*time.Second),
+			RetryOption(int(config.GetInt32("notify-retries", 2)), time.Second),
+		)
+	}
+
+	return limiter, nil
+}
+
+// Acquire blocks until the request may run, the returned func must be called
+// once the execution is finished.
+func (p *Limiter) Acquire(req *OutgoingRequest) (release func(), err error) {
+	if p == nil {
+		return func() {}, nil
+	}
+
+	p.Lock()
+
+	if p.running < p.max {
+		p.running++
+		p.Unlock()
+		return p.releaseFunc(), nil
+	}
+
+	if p.reject || len(p.waiters) >= p.queue {
+		p.Unlock()
+		return nil, ErrBusy
+	}
+
+	ch := make(chan struct{})
+	p.waiters = append(p.waiters, ch)
+	position := len(p.waiters)
+
+	p.Unlock()
+
+	var timeout <-chan time.Time
+	if p.timeout > 0 {
+		timer := time.NewTimer(p.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	// the webhook may be slow or retried, it must not delay the queue
+	go p.notify(req, position)
+
+	select {
+	case <-ch:
+		return p.releaseFunc(), nil
+	case <-timeout:
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	for i := 0; i < len(p.waiters); i++ {
+		if p.waiters[i] == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return nil, ErrQueueTimeout
+		}
+	}
+
+	// the slot was handed over while we were timing out
+	return p.releaseFunc(), nil
+}
+
+func (p *Limiter) releaseFunc() func() {
+	once := sync.Once{}
+	return func() {
+		once.Do(p.release)
+	}
+}
+
+func (p *Limiter) release() {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.waiters) > 0 {
+		next := p.waiters[0]
+		p.waiters = p.waiters[1:]
+		close(next)
+		return
+	}
+
+	p.running--
+}
+
+func (p *Limiter) notify(req *OutgoingRequest, position int) {
+	if p.client == nil || req == nil {
+		return
+	}
+
+	p.client.Send(p.notifyURL, &Message{
+		Text:    fmt.Sprintf("@%s your request `%s` is queued at position %d", req.UserName, req.Text, position),
+		Channel: req.ChannelName,
+	})
+}
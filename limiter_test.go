@@ -0,0 +1,119 @@
+package bearychat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestLimiterQueue(t *testing.T) {
+
+	limiter, err := NewLimiter(configuration.ParseString(`{ max = 1, queue = 1, timeout = 50ms }`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	release, err := limiter.Acquire(&OutgoingRequest{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		r, err := limiter.Acquire(&OutgoingRequest{})
+		if err == nil {
+			r()
+		}
+		acquired <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err = limiter.Acquire(&OutgoingRequest{}); err != ErrBusy {
+		t.Errorf("expected ErrBusy when queue is full, got: %v", err)
+		return
+	}
+
+	release()
+
+	if err = <-acquired; err != nil {
+		t.Error(err)
+		return
+	}
+
+	release, _ = limiter.Acquire(&OutgoingRequest{})
+	defer release()
+
+	if _, err = limiter.Acquire(&OutgoingRequest{}); err != ErrQueueTimeout {
+		t.Errorf("expected ErrQueueTimeout, got: %v", err)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+
+	if _, err := NewLimiter(configuration.ParseString(`{ max = 1, queue = 0 }`)); err == nil {
+		t.Error("wait mode without a queue should be rejected")
+	}
+
+	limiter, err := NewLimiter(configuration.ParseString(`{ max = 1 }`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	release, _ := limiter.Acquire(&OutgoingRequest{})
+
+	acquired := make(chan error, 1)
+	go func() {
+		r, err := limiter.Acquire(&OutgoingRequest{})
+		if err == nil {
+			r()
+		}
+		acquired <- err
+	}()
+
+	select {
+	case err = <-acquired:
+		t.Errorf("request should wait in the default queue, got: %v", err)
+		return
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+
+	if err = <-acquired; err != nil {
+		t.Errorf("waiting request should run once the slot is released: %v", err)
+	}
+}
+
+func TestLimiterSlowNotify(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Second)
+	}))
+	defer ts.Close()
+
+	limiter, err := NewLimiter(configuration.ParseString(`{ max = 1, timeout = 50ms, notify-url = "` + ts.URL + `" }`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	release, _ := limiter.Acquire(&OutgoingRequest{})
+	defer release()
+
+	start := time.Now()
+
+	if _, err = limiter.Acquire(&OutgoingRequest{UserName: "zeal"}); err != ErrQueueTimeout {
+		t.Errorf("expected ErrQueueTimeout, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("queue timeout should not wait for the notification, took %s", elapsed)
+	}
+}
@@ -0,0 +1,370 @@
+package bearychat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+const (
+	redacted = "******"
+)
+
+type RequestLogger interface {
+	Log(entry *RequestLog)
+}
+
+type DriverLog struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"-"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// RequestLog is recorded for every request handled by Outgoing.
+type RequestLog struct {
+	Time      time.Time     `json:"time"`
+	ID        string        `json:"request_id"`
+	Service   string        `json:"service,omitempty"`
+	Team      string        `json:"team,omitempty"`
+	Word      string        `json:"trigger_word"`
+	Commands  []string      `json:"commands,omitempty"`
+	User      string        `json:"user"`
+	Channel   string        `json:"channel"`
+	Subdomain string        `json:"subdomain,omitempty"`
+	Token     string        `json:"token,omitempty"`
+	Text      string        `json:"text"`
+	Drivers   []DriverLog   `json:"drivers,omitempty"`
+	Duration  time.Duration `json:"-"`
+	Status    int           `json:"status"`
+	Outcome   string        `json:"outcome"`
+	Error     string        `json:"error,omitempty"`
+}
+
+func newRequestLog(req *OutgoingRequest) *RequestLog {
+	return &RequestLog{
+		Time:      time.Now(),
+		ID:        req.ID,
+		Word:      req.TriggerWord,
+		User:      req.UserName,
+		Channel:   req.ChannelName,
+		Subdomain: req.Subdomain,
+		Token:     req.Token,
+		Text:      req.Text,
+	}
+}
+
+func (p *RequestLog) finish(err error) {
+	p.Duration = time.Since(p.Time)
+	p.Status, p.Outcome = ReplyStatus(err)
+
+	if err != nil && err != ErrBreakOnly && err != ErrNoContent {
+		p.Error = err.Error()
+	}
+}
+
+func (p *RequestLog) driver(name string, start time.Time, err error) {
+	d := DriverLog{
+		Name:     name,
+		Duration: time.Since(start),
+	}
+
+	if err != nil {
+		d.Error = err.Error()
+	}
+
+	p.Drivers = append(p.Drivers, d)
+}
+
+// ReplyStatus returns the http status and the outcome of a handled request,
+// the outcome is ok, break, no_content, user_error, permission_denied or
+// internal_error, as in request logs and metrics.
+func ReplyStatus(err error) (int, string) {
+	switch err {
+	case nil:
+		return 200, "ok"
+	case ErrBreakOnly:
+		return 200, "break"
+	case ErrNoContent:
+		return 204, "no_content"
+	}
+
+	switch KindOf(err) {
+	case ErrorKindUser:
+		return 200, "user_error"
+	case ErrorKindPermission:
+		return 200, "permission_denied"
+	}
+
+	return 200, "internal_error"
+}
+
+// Redactor masks secrets before request logs are written.
+type Redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+func NewRedactor(fields []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{
+		fields: make(map[string]bool),
+	}
+
+	for _, field := range fields {
+		r.fields[field] = true
+	}
+
+	for _, pattern := range patterns {
+		expr, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		r.patterns = append(r.patterns, expr)
+	}
+
+	return r, nil
+}
+
+func (p *Redactor) redact(entry *RequestLog) RequestLog {
+	e := *entry
+
+	if p == nil {
+		return e
+	}
+
+	e.Text = p.redactString(e.Text, entry)
+	e.Error = p.redactString(e.Error, entry)
+
+	e.Drivers = make([]DriverLog, len(entry.Drivers))
+	for i, d := range entry.Drivers {
+		d.Error = p.redactString(d.Error, entry)
+		e.Drivers[i] = d
+	}
+
+	if p.fields["token"] && len(e.Token) > 0 {
+		e.Token = redacted
+	}
+
+	if p.fields["text"] && len(e.Text) > 0 {
+		e.Text = redacted
+	}
+
+	return e
+}
+
+// redactString masks the patterns and the redacted fields of the entry which
+// are quoted in str, e.g. a driver error echoing the token.
+func (p *Redactor) redactString(str string, entry *RequestLog) string {
+	if len(str) == 0 {
+		return str
+	}
+
+	if p.fields["token"] && len(entry.Token) > 0 {
+		str = strings.Replace(str, entry.Token, redacted, -1)
+	}
+
+	if p.fields["text"] && len(entry.Text) > 0 && str != entry.Text {
+		str = strings.Replace(str, entry.Text, redacted, -1)
+	}
+
+	for i := 0; i < len(p.patterns); i++ {
+		str = p.patterns[i].ReplaceAllString(str, redacted)
+	}
+
+	return str
+}
+
+type jsonRequestLog struct {
+	RequestLog
+	Duration float64         `json:"duration_ms"`
+	Drivers  []jsonDriverLog `json:"drivers,omitempty"`
+}
+
+type jsonDriverLog struct {
+	DriverLog
+	Duration float64 `json:"duration_ms"`
+}
+
+type JSONLogger struct {
+	w        io.Writer
+	redactor *Redactor
+
+	sync.Mutex
+}
+
+func NewJSONLogger(w io.Writer, redactor *Redactor) *JSONLogger {
+	return &JSONLogger{w: w, redactor: redactor}
+}
+
+func (p *JSONLogger) Log(entry *RequestLog) {
+	e := p.redactor.redact(entry)
+
+	j := jsonRequestLog{
+		RequestLog: e,
+		Duration:   milliseconds(e.Duration),
+	}
+
+	for _, d := range e.Drivers {
+		j.Drivers = append(j.Drivers, jsonDriverLog{DriverLog: d, Duration: milliseconds(d.Duration)})
+	}
+
+	data, err := json.Marshal(j)
+	if err != nil {
+		return
+	}
+
+	p.Lock()
+	p.w.Write(append(data, '\n'))
+	p.Unlock()
+}
+
+type LogfmtLogger struct {
+	w        io.Writer
+	redactor *Redactor
+
+	sync.Mutex
+}
+
+func NewLogfmtLogger(w io.Writer, redactor *Redactor) *LogfmtLogger {
+	return &LogfmtLogger{w: w, redactor: redactor}
+}
+
+func (p *LogfmtLogger) Log(entry *RequestLog) {
+	e := p.redactor.redact(entry)
+
+	buf := bytes.NewBuffer(nil)
+
+	pair := func(key, value string) {
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(value))
+	}
+
+	pair("time", e.Time.Format(time.RFC3339Nano))
+	pair("request_id", e.ID)
+
+	if len(e.Service) > 0 {
+		pair("service", e.Service)
+	}
+
+	if len(e.Team) > 0 {
+		pair("team", e.Team)
+	}
+
+	pair("trigger_word", e.Word)
+	pair("commands", strings.Join(e.Commands, " "))
+	pair("user", e.User)
+	pair("channel", e.Channel)
+
+	if len(e.Subdomain) > 0 {
+		pair("subdomain", e.Subdomain)
+	}
+
+	if len(e.Token) > 0 {
+		pair("token", e.Token)
+	}
+
+	pair("text", e.Text)
+
+	for _, d := range e.Drivers {
+		pair("driver."+d.Name+".duration_ms", formatFloat(milliseconds(d.Duration)))
+		if len(d.Error) > 0 {
+			pair("driver."+d.Name+".error", d.Error)
+		}
+	}
+
+	pair("duration_ms", formatFloat(milliseconds(e.Duration)))
+	pair("status", strconv.Itoa(e.Status))
+	pair("outcome", e.Outcome)
+
+	if len(e.Error) > 0 {
+		pair("error", e.Error)
+	}
+
+	buf.WriteByte('\n')
+
+	p.Lock()
+	p.w.Write(buf.Bytes())
+	p.Unlock()
+}
+
+func logfmtValue(value string) string {
+	if len(value) == 0 {
+		return `""`
+	}
+
+	if strings.ContainsAny(value, " =\"\t\r\n") {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
+
+// NewRequestLogger creates a request logger by config:
+//
+//	log {
+//	    format = json            // json or logfmt
+//	    output = stdout          // stdout, stderr or a file path
+//	    redact {
+//	        fields   = [token]   // token, text
+//	        patterns = ["password=\\S+"]
+//	    }
+//	}
+func NewRequestLogger(config *configuration.Config) (RequestLogger, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	fields := []string{"token"}
+	if config.HasPath("redact.fields") {
+		fields = config.GetStringList("redact.fields")
+	}
+
+	redactor, err := NewRedactor(fields, config.GetStringList("redact.patterns"))
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.Writer
+
+	switch output := config.GetString("output", "stdout"); output {
+	case "stdout":
+		w = os.Stdout
+	case "stderr":
+		w = os.Stderr
+	default:
+		w, err = os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch format := config.GetString("format", "json"); format {
+	case "json":
+		return NewJSONLogger(w, redactor), nil
+	case "logfmt":
+		return NewLogfmtLogger(w, redactor), nil
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
+	}
+}
@@ -0,0 +1,90 @@
+package bearychat
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestRequestLogger(t *testing.T) {
+
+	redactor, err := NewRedactor([]string{"token"}, []string{`password=\S+`})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	config := configuration.ParseString(`{
+		deploy {
+			word = "!cmd"
+			commands = [deploy]
+			drivers = [test-counter, test-failure]
+		}
+	}`)
+
+	outgoing, err := NewOutgoing(config, RegistryOption(testRegistry()), LoggerOption(NewJSONLogger(buf, redactor)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	req := &OutgoingRequest{Token: "s3cret", Text: "!cmd deploy password=123", TriggerWord: "!cmd", UserName: "zeal", ChannelName: "ops"}
+	outgoing.Handle(req, &Message{})
+
+	entry := struct {
+		ID       string   `json:"request_id"`
+		Token    string   `json:"token"`
+		Text     string   `json:"text"`
+		Commands []string `json:"commands"`
+		Outcome  string   `json:"outcome"`
+		Drivers  []struct {
+			Name  string `json:"name"`
+			Error string `json:"error"`
+		} `json:"drivers"`
+	}{}
+
+	if err = json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if entry.ID != req.ID || len(entry.ID) == 0 {
+		t.Errorf("bad request id: %s", entry.ID)
+	}
+
+	if entry.Token != "******" || strings.Contains(entry.Text, "123") {
+		t.Errorf("secrets should be redacted: %s %s", entry.Token, entry.Text)
+	}
+
+	if strings.Join(entry.Commands, " ") != "deploy" || entry.Outcome != "internal_error" {
+		t.Errorf("bad commands or outcome: %v %s", entry.Commands, entry.Outcome)
+	}
+
+	if len(entry.Drivers) != 2 || entry.Drivers[0].Name != "test-counter" || len(entry.Drivers[1].Error) == 0 {
+		t.Errorf("bad drivers: %v", entry.Drivers)
+	}
+}
+
+func TestRedactErrors(t *testing.T) {
+
+	redactor, err := NewRedactor([]string{"token"}, []string{`password=\S+`})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	e := redactor.redact(&RequestLog{
+		Token:   "s3cret",
+		Error:   "bad token s3cret",
+		Drivers: []DriverLog{{Name: "cmd", Error: "deploy password=123 failed with s3cret"}},
+	})
+
+	if e.Error != "bad token ******" || e.Drivers[0].Error != "deploy ****** failed with ******" {
+		t.Errorf("error strings should be redacted: %q %q", e.Error, e.Drivers[0].Error)
+	}
+}
@@ -0,0 +1,118 @@
+package bearychat
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	codeBlockExpr  = regexp.MustCompile("(?s)```(?:[a-zA-Z0-9_+-]*\\n)?(.*?)```")
+	inlineCodeExpr = regexp.MustCompile("`([^`\n]+)`")
+	mentionExpr    = regexp.MustCompile(`@<=([^=>\s]+)=>|(?:^|[^\w@])@([\w.-]+)`)
+	channelExpr    = regexp.MustCompile(`#<=([^=>\s]+)=>|(?:^|[^\w#&])#([\w.-]+)`)
+	urlExpr        = regexp.MustCompile(`<?(https?://[^\s<>]+)>?`)
+)
+
+// Mention is a mentioned user, ID is set for the @<=id=> markup and Name for
+// a plain @name.
+type Mention struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// ChannelRef is a referenced channel, ID is set for the #<=id=> markup and
+// Name for a plain #name.
+type ChannelRef struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Entities are the structured parts of a message text, mentions, channels
+// and urls inside code spans are ignored.
+type Entities struct {
+	Mentions []Mention    `json:"mentions,omitempty"`
+	Channels []ChannelRef `json:"channels,omitempty"`
+	URLs     []string     `json:"urls,omitempty"`
+	Code     []string     `json:"code,omitempty"`
+}
+
+// Entities parses the text of the request.
+func (p *OutgoingRequest) Entities() *Entities {
+	return ParseEntities(p.Text)
+}
+
+// Mentioned reports whether the user of id or name is mentioned.
+func (p *Entities) Mentioned(idOrName string) bool {
+	for _, m := range p.Mentions {
+		if (len(m.ID) > 0 && m.ID == idOrName) || (len(m.Name) > 0 && m.Name == idOrName) {
+			return true
+		}
+	}
+	return false
+}
+
+func ParseEntities(text string) *Entities {
+	e := &Entities{}
+
+	// the code spans are cut out before looking for the other entities
+	text = codeBlockExpr.ReplaceAllStringFunc(text, func(block string) string {
+		e.Code = append(e.Code, codeBlockExpr.FindStringSubmatch(block)[1])
+		return " "
+	})
+
+	text = inlineCodeExpr.ReplaceAllStringFunc(text, func(span string) string {
+		e.Code = append(e.Code, inlineCodeExpr.FindStringSubmatch(span)[1])
+		return " "
+	})
+
+	text = urlExpr.ReplaceAllStringFunc(text, func(str string) string {
+		url := urlExpr.FindStringSubmatch(str)[1]
+		if !strings.HasPrefix(str, "<") {
+			url = strings.TrimRight(url, ".,;:!?)'\"")
+		}
+		e.URLs = append(e.URLs, url)
+		return " "
+	})
+
+	for _, m := range mentionExpr.FindAllStringSubmatch(text, -1) {
+		e.Mentions = append(e.Mentions, Mention{ID: m[1], Name: strings.TrimRight(m[2], ".-")})
+	}
+
+	for _, m := range channelExpr.FindAllStringSubmatch(text, -1) {
+		e.Channels = append(e.Channels, ChannelRef{ID: m[1], Name: strings.TrimRight(m[2], ".-")})
+	}
+
+	return e
+}
+
+// MentionUser returns the markup mentioning the user of id.
+func MentionUser(id string) string {
+	return "@<=" + id + "=>"
+}
+
+// MentionName returns a plain @name mention.
+func MentionName(name string) string {
+	return "@" + name
+}
+
+// ChannelLink returns the markup referencing the channel of id.
+func ChannelLink(id string) string {
+	return "#<=" + id + "=>"
+}
+
+func InlineCode(code string) string {
+	return "`" + strings.Replace(code, "`", "'", -1) + "`"
+}
+
+func CodeBlock(code string) string {
+	return "```\n" + strings.TrimRight(code, "\n") + "\n```"
+}
+
+// MentionRequester mentions the user who sent the request, by id if
+// BearyChat sent it.
+func (p *OutgoingRequest) MentionRequester() string {
+	if len(p.UserID) > 0 {
+		return MentionUser(p.UserID)
+	}
+	return MentionName(p.UserName)
+}
@@ -0,0 +1,44 @@
+package bearychat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEntities(t *testing.T) {
+
+	text := "!deploy @<=bw52O=> @zeal to #ops and #<=bw52P=>, see https://example.com/a?b=1. " +
+		"and <http://example.com/x(1)> `@not #this` mail a@b.com\n```sh\nrm -rf @tmp\n```"
+
+	e := ParseEntities(text)
+
+	if !reflect.DeepEqual(e.Mentions, []Mention{{ID: "bw52O"}, {Name: "zeal"}}) {
+		t.Errorf("bad mentions: %v", e.Mentions)
+	}
+
+	if !reflect.DeepEqual(e.Channels, []ChannelRef{{Name: "ops"}, {ID: "bw52P"}}) {
+		t.Errorf("bad channels: %v", e.Channels)
+	}
+
+	if !reflect.DeepEqual(e.URLs, []string{"https://example.com/a?b=1", "http://example.com/x(1)"}) {
+		t.Errorf("bad urls: %v", e.URLs)
+	}
+
+	if !reflect.DeepEqual(e.Code, []string{"rm -rf @tmp\n", "@not #this"}) {
+		t.Errorf("bad code spans: %v", e.Code)
+	}
+
+	if !e.Mentioned("zeal") || !e.Mentioned("bw52O") || e.Mentioned("b.com") {
+		t.Error("bad mentioned")
+	}
+
+	req := &OutgoingRequest{UserName: "zeal"}
+	if req.MentionRequester() != "@zeal" {
+		t.Errorf("bad requester mention: %s", req.MentionRequester())
+	}
+
+	req.UserID = "bw52O"
+	if mentions := ParseEntities(req.MentionRequester() + " done").Mentions; len(mentions) != 1 || mentions[0].ID != "bw52O" {
+		t.Errorf("built mention should be parsed back: %v", mentions)
+	}
+}
@@ -0,0 +1,12 @@
+package bearychat
+
+import (
+	"github.com/gogap/bearychat/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterMetrics registers the outgoing and incoming metrics to reg, e.g.
+// prometheus.DefaultRegisterer or a registry of the application.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	return metrics.Register(reg)
+}
@@ -4,22 +4,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-akka/configuration"
 	"github.com/gogap/bearychat/internal"
+	"github.com/gogap/bearychat/internal/metrics"
 )
 
 const (
 	OUTGOING = "gogap-outgoing"
 )
 
-var (
-	triggerFuncs = make(map[string]NewTriggerFunc)
-)
-
 var (
 	ErrTriggerDriverAlreadyRegistered = errors.New("trigger driver already registered")
 	ErrNewTriggerFuncIsNil            = errors.New("trigger func is nil")
@@ -38,6 +40,13 @@ type Outgoing struct {
 
 	config       *configuration.Config
 	errorHandler ErrorHandlerFunc
+
+	dedup *dedupCache
+
+	middlewares []Middleware
+
+	// guards triggers and middlewares, which may be bound while serving
+	sync.RWMutex
 }
 
 func init() {
@@ -45,43 +54,33 @@ func init() {
 }
 
 func TriggerDrivers() []string {
-	var ret []string
-	for k, _ := range triggerFuncs {
-		ret = append(ret, k)
-	}
-
-	sort.Sort(sort.StringSlice(ret))
-
-	return ret
+	return DefaultRegistry.Drivers()
 }
 
 func RegisterTriggerDriver(name string, fn NewTriggerFunc) {
-	if fn == nil {
-		panic(ErrNewTriggerFuncIsNil)
+	if err := DefaultRegistry.Register(name, fn); err != nil {
+		panic(err)
 	}
-
-	_, exist := triggerFuncs[name]
-	if exist {
-		panic(ErrTriggerDriverAlreadyRegistered)
-	}
-	triggerFuncs[name] = fn
 }
 
-func NewOutgoing(config *configuration.Config) (*Outgoing, error) {
+func NewOutgoing(config *configuration.Config, opts ...OutgoingOption) (*Outgoing, error) {
+
+	settings := NewOutgoingSettings(config, opts...)
 
 	outgoing := &Outgoing{
 		triggers: make(map[string]*internal.Command),
 		config:   config,
-		settings: NewOutgoingSettings(config),
+		settings: settings,
+		dedup:    newDedupCache(settings.Clock),
 	}
 
-	outgoing.errorHandler = outgoing.handleError
-
 	outgoing.autoBind(config)
 
 	return outgoing, nil
 }
 
+// NewOutgoingTrigger creates the Outgoing of a gogap-outgoing driver bound
+// without a parent, the drivers bound by an Outgoing use its settings.
 func NewOutgoingTrigger(word string, config *configuration.Config) (Trigger, error) {
 	return NewOutgoing(config)
 }
@@ -104,15 +103,23 @@ func (p *Outgoing) BindTrigger(config *configuration.Config) *Outgoing {
 
 	names := removeDuplicates(drivers)
 
-	var triggers []interface{}
+	var triggers []Trigger
 
 	for i := 0; i < len(names); i++ {
-		triggerDriver, exist := triggerFuncs[names[i]]
-		if !exist {
-			panic(fmt.Errorf("the trigger of %s did not exist", names[i]))
+		var trigger Trigger
+		var err error
+
+		if names[i] == OUTGOING {
+			trigger, err = p.nested(config.GetConfig(names[i]))
+		} else {
+			triggerDriver, exist := p.registry().Lookup(names[i])
+			if !exist {
+				panic(fmt.Errorf("the trigger of %s did not exist", names[i]))
+			}
+
+			trigger, err = triggerDriver(triggerWord, config.GetConfig(names[i]))
 		}
 
-		trigger, err := triggerDriver(triggerWord, config.GetConfig(names[i]))
 		if err != nil {
 			panic(err)
 		}
@@ -120,18 +127,157 @@ func (p *Outgoing) BindTrigger(config *configuration.Config) *Outgoing {
 		triggers = append(triggers, trigger)
 	}
 
-	root, exist := p.triggers[triggerWord]
+	limiter, err := NewLimiter(config.GetConfig("concurrency"))
+	if err != nil {
+		panic(err)
+	}
+
+	b := &binding{
+		word:     triggerWord,
+		commands: commands,
+		drivers:  names,
+		triggers: triggers,
+		limiter:  limiter,
+	}
+
+	b.errorTemplate, err = NewErrorTemplate(config.GetConfig("error"))
+	if err != nil {
+		panic(err)
+	}
+
+	if dedupConfig := config.GetConfig("dedup"); dedupConfig != nil && dedupConfig.GetBoolean("enabled", true) {
+		b.dedupTTL = dedupConfig.GetTimeDuration("ttl", 10*time.Minute)
+	}
+
+	if err = p.bind(b); err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// nested creates the Outgoing of a gogap-outgoing driver with the registry,
+// clock and other settings of p. Requests are logged, audited and recorded
+// by p only, which also counts them in the metrics.
+func (p *Outgoing) nested(config *configuration.Config) (*Outgoing, error) {
+	return NewOutgoing(config, func(s *OutgoingSettings) {
+		*s = *p.settings
+		s.Logger = nil
+		s.Audit = nil
+		s.Recorder = nil
+		s.nested = true
+	})
+}
+
+func (p *Outgoing) registry() *Registry {
+	if p.settings.Registry != nil {
+		return p.settings.Registry
+	}
+	return DefaultRegistry
+}
+
+// BindingOption configures a binding made by HandleFunc, HandleTrigger or
+// HandleArgs, like the concurrency, dedup and error sections of a trigger
+// bound by config.
+type BindingOption func(*binding)
+
+// BindLimiter bounds the parallel executions of the binding, see NewLimiter.
+func BindLimiter(limiter *Limiter) BindingOption {
+	return func(b *binding) {
+		b.limiter = limiter
+	}
+}
+
+// BindDedup replies to the duplicated requests within ttl with the reply of
+// the first one.
+func BindDedup(ttl time.Duration) BindingOption {
+	return func(b *binding) {
+		b.dedupTTL = ttl
+	}
+}
+
+// BindErrorTemplate renders the errors of the binding, see NewErrorTemplate.
+func BindErrorTemplate(tmpl *ErrorTemplate) BindingOption {
+	return func(b *binding) {
+		b.errorTemplate = tmpl
+	}
+}
+
+// HandleFunc binds fn to the word and sub-commands, like a trigger bound
+// by config.
+func (p *Outgoing) HandleFunc(word string, commands []string, fn TriggerHandleFunc, opts ...BindingOption) error {
+	return p.HandleTrigger(word, commands, []Trigger{fn}, opts...)
+}
+
+// HandleTrigger binds the triggers, executed in order, to the word and
+// sub-commands, like the drivers bound by config. It is safe to bind while
+// requests are handled.
+func (p *Outgoing) HandleTrigger(word string, commands []string, triggers []Trigger, opts ...BindingOption) error {
+	word = strings.TrimSpace(word)
+
+	if len(word) == 0 {
+		return errors.New("trigger word is empty")
+	}
+
+	if len(triggers) == 0 {
+		return errors.New("no trigger to handle " + word)
+	}
+
+	b := &binding{
+		word:     word,
+		commands: commands,
+		triggers: triggers,
+	}
+
+	for _, trigger := range triggers {
+		b.drivers = append(b.drivers, triggerName(trigger))
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return p.bind(b)
+}
+
+// Use appends middlewares wrapping the triggers of every matched request.
+func (p *Outgoing) Use(middlewares ...Middleware) *Outgoing {
+	p.Lock()
+	defer p.Unlock()
+
+	p.middlewares = append(p.middlewares, middlewares...)
+	return p
+}
+
+func triggerName(trigger Trigger) string {
+	switch trigger.(type) {
+	case TriggerHandleFunc, *argsHandler:
+		return "func"
+	}
+
+	return strings.TrimPrefix(fmt.Sprintf("%T", trigger), "*")
+}
+
+func (p *Outgoing) bind(b *binding) error {
+	p.Lock()
+	defer p.Unlock()
+
+	root, exist := p.triggers[b.word]
 	if !exist {
 		root = &internal.Command{}
 	}
 
-	node := root.Match(commands...)
+	node := root.Match(b.commands...)
 
-	if len(node.Values) > 0 {
-		panic(fmt.Errorf("command alrady has triggers: %s", strings.Join(commands, " ")))
+	if len(node.Values) > 0 && len(node.Commands()) == len(b.commands) {
+		return fmt.Errorf("command alrady has triggers: %s", strings.Join(append([]string{b.word}, b.commands...), " "))
 	}
 
-	subCommands := commands[len(node.Commands()):]
+	subCommands := b.commands[len(node.Commands()):]
+
+	if len(subCommands) == 0 {
+		node.Values = []interface{}{b}
+	}
 
 	for i := 0; i < len(subCommands); i++ {
 
@@ -140,89 +286,307 @@ func (p *Outgoing) BindTrigger(config *configuration.Config) *Outgoing {
 		}
 
 		if i+1 == len(subCommands) {
-			child.Values = triggers
+			child.Values = []interface{}{b}
 		}
 
 		node.AddChild(child)
 		node = child
 	}
 
-	p.triggers[triggerWord] = root
+	p.triggers[b.word] = root
 
-	return p
+	return nil
+}
+
+// Words returns the bound trigger words.
+func (p *Outgoing) Words() []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	var words []string
+	for word := range p.triggers {
+		words = append(words, word)
+	}
+
+	sort.Strings(words)
+
+	return words
+}
+
+// Token returns a token accepted by the triggers bound to the trigger word of
+// req, it is empty if none of them provides one.
+func (p *Outgoing) Token(req *OutgoingRequest) string {
+	p.RLock()
+	defer p.RUnlock()
+
+	root, exist := p.triggers[req.TriggerWord]
+	if !exist {
+		return ""
+	}
+
+	var token string
+
+	var walk func(node *internal.Command)
+	walk = func(node *internal.Command) {
+		for i := 0; i < len(node.Values) && len(token) == 0; i++ {
+			b, ok := node.Values[i].(*binding)
+			if !ok {
+				continue
+			}
+
+			for j := 0; j < len(b.triggers) && len(token) == 0; j++ {
+				switch t := b.triggers[j].(type) {
+				case TokenProvider:
+					token = t.Token(p.settings.Clock())
+				case *Outgoing:
+					token = t.Token(req)
+				}
+			}
+		}
+
+		for i := 0; i < len(node.Children) && len(token) == 0; i++ {
+			walk(node.Children[i])
+		}
+	}
+
+	walk(root)
+
+	return token
+}
+
+// DetectTriggerWord returns the longest of words text starts with, e.g.
+// !deploy-all over !deploy, or the first field of text if none matches.
+func DetectTriggerWord(words []string, text string) string {
+	text = strings.TrimSpace(text)
+
+	sorted := append([]string(nil), words...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	for _, word := range sorted {
+		if strings.HasPrefix(text, word) {
+			return word
+		}
+	}
+
+	if fields := strings.Fields(text); len(fields) > 0 {
+		return fields[0]
+	}
+
+	return ""
 }
 
 func (p *Outgoing) SetErrorHandler(handler ErrorHandlerFunc) {
+	p.Lock()
+	defer p.Unlock()
+
 	p.errorHandler = handler
-	if p.errorHandler == nil {
-		p.errorHandler = p.handleError
+}
+
+func (p *Outgoing) Handle(req *OutgoingRequest, msg *Message) (err error) {
+
+	if len(req.ID) == 0 {
+		req.ID = newReference()
 	}
+
+	if req.clock == nil {
+		req.clock = p.settings.Clock
+	}
+
+	entry := newRequestLog(req)
+	entry.Service = p.settings.Service
+	entry.Team = p.settings.Team
+
+	if !p.settings.nested {
+		metrics.InFlight.Inc()
+	}
+
+	var b *binding
+
+	defer func() {
+		// drivers like gogap-confirm may replace the request by an earlier one
+		req.ID = entry.ID
+
+		entry.finish(err)
+
+		if !p.settings.nested {
+			metrics.InFlight.Dec()
+			p.observe(b, entry)
+		}
+
+		if b != nil {
+			p.audit(req, entry, msg)
+		}
+
+		if p.settings.Logger != nil {
+			p.settings.Logger.Log(entry)
+		} else if entry.Outcome == "internal_error" {
+			// the reference shown to the user is the request id
+			log.Printf("[bearychat] internal error, reference: %s, word: %s, user: %s, error: %s",
+				entry.ID, entry.Word, entry.User, redactToken([]byte(entry.Error), entry.Token))
+		}
+	}()
+
+	p.RLock()
+	b, err = p.match(req)
+	var handle TriggerHandleFunc
+	if err == nil {
+		handle = p.chain(func(req *OutgoingRequest, msg *Message) error {
+			return b.Handle(req, msg, entry)
+		})
+	}
+	p.RUnlock()
+
+	if err != nil {
+		return
+	}
+
+	entry.Commands = req.Commands
+
+	if b.dedupTTL > 0 {
+		return p.dedup.Do(dedupKey(req), b.dedupTTL, msg, func(msg *Message) error {
+			return handle(req, msg)
+		})
+	}
+
+	return handle(req, msg)
+}
+
+func (p *Outgoing) chain(fn TriggerHandleFunc) TriggerHandleFunc {
+	for i := len(p.middlewares) - 1; i >= 0; i-- {
+		fn = p.middlewares[i](fn)
+	}
+	return fn
 }
 
-func (p *Outgoing) Handle(req *OutgoingRequest, msg *Message) error {
+func (p *Outgoing) match(req *OutgoingRequest) (*binding, error) {
 
 	word := strings.TrimSpace(req.TriggerWord)
 	treeRoot, exist := p.triggers[word]
 
 	if !exist {
-		return fmt.Errorf("trigger of %s not exist!", word)
+		return nil, UserError(fmt.Errorf("trigger of %s not exist!", word))
 	}
 
 	args := req.Args()
 
 	node := treeRoot.Match(args...)
 
-	if node == treeRoot {
-		return fmt.Errorf("unknown sub-command: %s", strings.Join(args, " "))
+	if node == treeRoot && len(node.Values) == 0 {
+		return nil, UserError(fmt.Errorf("unknown sub-command: %s", strings.Join(args, " ")))
 	}
 
 	if len(node.Values) == 0 {
-		return fmt.Errorf("unfinished sub-command")
+		return nil, UserError(fmt.Errorf("unfinished sub-command"))
 	}
 
 	req.Commands = node.Commands()
 
-	for i := 0; i < len(node.Values); i++ {
-		if err := node.Values[i].(Trigger).Handle(req, msg); err != nil {
-			return err
-		}
+	return node.Values[0].(*binding), nil
+}
+
+func (p *Outgoing) observe(b *binding, entry *RequestLog) {
+	word, command := "unmatched", ""
+
+	if b != nil {
+		word, command = b.word, strings.Join(b.commands, " ")
 	}
 
-	return nil
+	metrics.Requests.WithLabelValues(p.settings.Service, p.settings.Team, word, command, entry.Outcome).Inc()
+	metrics.RequestDuration.WithLabelValues(p.settings.Service, p.settings.Team, word).Observe(entry.Duration.Seconds())
 }
 
 func (p *Outgoing) HandleHttpRequest(rw http.ResponseWriter, req *http.Request) {
 
+	triggerReq, ok := p.decodeHttpRequest(rw, req)
+	if !ok {
+		return
+	}
+
+	p.writeReply(rw, triggerReq)
+}
+
+// decodeHttpRequest decodes the outgoing request, the response is written
+// already if ok is false.
+func (p *Outgoing) decodeHttpRequest(rw http.ResponseWriter, req *http.Request) (triggerReq *OutgoingRequest, ok bool) {
+
 	if req.Method != "POST" {
 		rw.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	decoder := json.NewDecoder(req.Body)
-	decoder.UseNumber()
+	if p.settings.MaxBodySize > 0 {
+		req.Body = http.MaxBytesReader(rw, req.Body, p.settings.MaxBodySize)
+	}
 
-	triggerReq := &OutgoingRequest{}
-	err := decoder.Decode(triggerReq)
+	contentType := req.Header.Get("Content-Type")
 
-	statusCode := 200
+	body, err := ioutil.ReadAll(req.Body)
+	if err == nil {
+		triggerReq, err = decodeOutgoingRequest(contentType, body, p.settings.Strict)
+	}
 
-	msg := Message{}
-	if err != nil {
-		msg = p.errorHandler(err)
-	} else {
-		err = p.Handle(triggerReq, &msg)
-		if err == ErrBreakOnly {
-			err = nil
-		} else if err == ErrNoContent {
-			err = nil
-			statusCode = 204
-		}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	case err == ErrUnsupportedMediaType:
+		http.Error(rw, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		if err != nil {
-			msg = p.errorHandler(err)
-		}
+	triggerReq.contentType = contentType
+	triggerReq.body = body
+
+	return triggerReq, true
+}
+
+func (p *Outgoing) writeReply(rw http.ResponseWriter, req *OutgoingRequest) {
+	statusCode, msg := p.Reply(req)
+	writeMessage(rw, statusCode, msg)
+}
+
+// Reply handles req and returns the http status code and the message to
+// answer with, errors are rendered into the message.
+func (p *Outgoing) Reply(req *OutgoingRequest) (statusCode int, msg Message) {
+	statusCode, msg, _ = p.Execute(req)
+	return
+}
+
+// Execute is Reply which also returns the error of the handling,
+// ErrBreakOnly and ErrNoContent included, the message of a failed request
+// is the rendered error.
+func (p *Outgoing) Execute(req *OutgoingRequest) (statusCode int, msg Message, err error) {
+
+	if len(req.ID) == 0 {
+		req.ID = newReference()
 	}
 
+	if p.settings.Recorder != nil {
+		raw := *req
+		defer func() {
+			p.record(&raw, statusCode, msg)
+		}()
+	}
+
+	err = p.Handle(req, &msg)
+
+	statusCode, _ = ReplyStatus(err)
+
+	if err != nil && err != ErrBreakOnly && err != ErrNoContent {
+		msg = p.renderError(req, err)
+	}
+
+	return
+}
+
+func writeMessage(rw http.ResponseWriter, statusCode int, msg Message) {
+
 	jsonMsg, _ := json.Marshal(msg)
 
 	rw.Header().Set("Content-Type", "application/json")
@@ -234,12 +598,98 @@ func (p *Outgoing) HandleHttpRequest(rw http.ResponseWriter, req *http.Request)
 	}
 }
 
-func (p *Outgoing) handleError(cause error) Message {
+func (p *Outgoing) renderError(req *OutgoingRequest, cause error) Message {
+	if tmpl := p.errorTemplate(req); tmpl != nil {
+		return tmpl.Render(newErrorData(req, cause))
+	}
+
+	p.RLock()
+	handler := p.errorHandler
+	p.RUnlock()
+
+	if handler != nil {
+		return handler(cause)
+	}
+
+	if p.settings.ErrorTemplate != nil {
+		return p.settings.ErrorTemplate.Render(newErrorData(req, cause))
+	}
+
+	return p.handleError(req, cause)
+}
+
+// errorTemplate returns the error template of the binding matched by req.
+func (p *Outgoing) errorTemplate(req *OutgoingRequest) *ErrorTemplate {
+	p.RLock()
+	defer p.RUnlock()
+
+	if root, exist := p.triggers[strings.TrimSpace(req.TriggerWord)]; exist {
+		if node := root.Match(req.Commands...); len(node.Values) > 0 {
+			return node.Values[0].(*binding).errorTemplate
+		}
+	}
+
+	return nil
+}
+
+func (p *Outgoing) handleError(req *OutgoingRequest, cause error) Message {
+	data := newErrorData(req, cause)
+
 	return Message{
-		Text: cause.Error(),
+		Text: execTemplate(defaultErrorTemplate, data),
 	}
 }
 
+// Close closes the bound triggers which implement io.Closer, e.g. to
+// terminate the processes started by gogap-commands on shutdown.
+func (p *Outgoing) Close() error {
+	var errs []string
+
+	p.dedup.Close()
+
+	p.RLock()
+	defer p.RUnlock()
+
+	for _, b := range p.bindings() {
+		for i := 0; i < len(b.triggers); i++ {
+			if closer, ok := b.triggers[i].(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (p *Outgoing) bindings() []*binding {
+	var ret []*binding
+
+	var walk func(node *internal.Command)
+	walk = func(node *internal.Command) {
+		for i := 0; i < len(node.Values); i++ {
+			if b, ok := node.Values[i].(*binding); ok {
+				ret = append(ret, b)
+			}
+		}
+
+		for i := 0; i < len(node.Children); i++ {
+			walk(node.Children[i])
+		}
+	}
+
+	for _, root := range p.triggers {
+		walk(root)
+	}
+
+	return ret
+}
+
 func (p *Outgoing) autoBind(config *configuration.Config) {
 	if config == nil {
 		return
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gogap/bearychat"
+	"github.com/urfave/cli"
+)
+
+const chatHelp = `type a message as in a BearyChat channel, e.g. "!deploy web"
+  /user NAME      change the user
+  /channel NAME   change the channel
+  /words          list the trigger words
+  /quit           exit`
+
+// cmdChat is a local chat simulator, every line is sent to the configured
+// Outgoing and the reply is printed.
+func cmdChat(c *cli.Context) (err error) {
+	config := loadConfig(c)
+
+	services, err := initServices(config)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		for _, svc := range services {
+			svc.handler.Close()
+		}
+	}()
+
+	svc, err := findService(services, c.String(ServiceFlag.Name))
+	if err != nil {
+		return
+	}
+
+	s := &chat{
+		handler:   svc.handler,
+		user:      c.String(UserFlag.Name),
+		channel:   c.String(ChannelFlag.Name),
+		subdomain: c.String(SubdomainFlag.Name),
+		token:     c.String(TokenFlag.Name),
+		out:       os.Stdout,
+	}
+
+	fmt.Fprintln(s.out, chatHelp)
+
+	return s.run(os.Stdin)
+}
+
+type chat struct {
+	handler   outgoingHandler
+	user      string
+	channel   string
+	subdomain string
+	token     string
+
+	out io.Writer
+}
+
+func (p *chat) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+
+	for {
+		fmt.Fprintf(p.out, "%s@%s> ", p.user, p.channel)
+
+		if !scanner.Scan() {
+			fmt.Fprintln(p.out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") {
+			if quit := p.command(line); quit {
+				return nil
+			}
+			continue
+		}
+
+		req := &bearychat.OutgoingRequest{
+			Token:       p.token,
+			Timestamp:   time.Now().Unix(),
+			Text:        line,
+			TriggerWord: bearychat.DetectTriggerWord(p.handler.Words(), line),
+			Subdomain:   p.subdomain,
+			ChannelName: p.channel,
+			UserName:    p.user,
+		}
+
+		if len(req.Token) == 0 {
+			req.Token = p.handler.Token(req)
+		}
+
+		status, msg := p.handler.Reply(req)
+
+		if status == 204 {
+			fmt.Fprintln(p.out, "(no content)")
+			continue
+		}
+
+		fmt.Fprint(p.out, renderMessage(msg))
+	}
+}
+
+func (p *chat) command(line string) (quit bool) {
+	fields := strings.Fields(line)
+
+	switch fields[0] {
+	case "/quit", "/exit":
+		return true
+	case "/user":
+		if len(fields) == 2 {
+			p.user = fields[1]
+			return
+		}
+	case "/channel":
+		if len(fields) == 2 {
+			p.channel = fields[1]
+			return
+		}
+	case "/words":
+		fmt.Fprintln(p.out, strings.Join(p.handler.Words(), " "))
+		return
+	}
+
+	fmt.Fprintln(p.out, chatHelp)
+
+	return
+}
+
+func renderMessage(msg bearychat.Message) string {
+	buf := &strings.Builder{}
+
+	if len(msg.Text) > 0 {
+		for _, line := range strings.Split(msg.Text, "\n") {
+			fmt.Fprintf(buf, "  %s\n", line)
+		}
+	}
+
+	for _, attachment := range msg.Attachments {
+		title := attachment.Title
+		if len(attachment.Color) > 0 {
+			title = fmt.Sprintf("[%s] %s", attachment.Color, title)
+		}
+
+		if len(title) > 0 {
+			fmt.Fprintf(buf, "  | %s\n", title)
+		}
+
+		if len(attachment.Text) > 0 {
+			for _, line := range strings.Split(attachment.Text, "\n") {
+				fmt.Fprintf(buf, "  | %s\n", line)
+			}
+		}
+
+		for _, image := range attachment.Images {
+			fmt.Fprintf(buf, "  | image: %s\n", image.URL)
+		}
+	}
+
+	if buf.Len() == 0 {
+		return "  (empty reply)\n"
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+func TestRenderMessage(t *testing.T) {
+
+	msg := bearychat.Message{
+		Text: "deployed\nweb",
+		Attachments: []bearychat.Attachment{
+			{Title: "status", Color: "#00ff00", Text: "ok", Images: []bearychat.Image{{URL: "http://img"}}},
+		},
+	}
+
+	expected := "  deployed\n  web\n  | [#00ff00] status\n  | ok\n  | image: http://img\n"
+
+	if out := renderMessage(msg); out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	if out := renderMessage(bearychat.Message{}); out != "  (empty reply)\n" {
+		t.Errorf("empty message should be rendered as empty reply, got %q", out)
+	}
+}
+
+func TestChat(t *testing.T) {
+
+	handler, err := initOutgoingHandler(configuration.ParseString(`{
+		outgoing.deploy {
+			word = "!deploy"
+			drivers = [gogap-auth]
+			gogap-auth.token = "secret"
+		}
+	}`))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	defer handler.Close()
+
+	out := bytes.NewBuffer(nil)
+
+	s := &chat{handler: handler, user: "zeal", channel: "ops", out: out}
+
+	if err = s.run(strings.NewReader("!deploy web\n/user alice\n/quit\n")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !strings.Contains(out.String(), "zeal@ops> ") || !strings.Contains(out.String(), "alice@ops> ") {
+		t.Errorf("prompt should show the user and the channel: %s", out)
+	}
+
+	if !strings.Contains(out.String(), "(empty reply)") || strings.Contains(out.String(), "error auth token") {
+		t.Errorf("token of gogap-auth should be filled: %s", out)
+	}
+}
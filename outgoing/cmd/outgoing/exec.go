@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gogap/bearychat"
+	"github.com/urfave/cli"
+)
+
+type execResult struct {
+	Status  int                `json:"status"`
+	Outcome string             `json:"outcome"`
+	Error   string             `json:"error,omitempty"`
+	Message *bearychat.Message `json:"message,omitempty"`
+}
+
+// cmdExec handles the outgoing requests read as JSON documents from the
+// input and writes a JSON line for each reply, without serving http.
+func cmdExec(c *cli.Context) (err error) {
+	config := loadConfig(c)
+
+	services, err := initServices(config)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		for _, svc := range services {
+			svc.handler.Close()
+		}
+	}()
+
+	svc, err := findService(services, c.String(ServiceFlag.Name))
+	if err != nil {
+		return
+	}
+
+	var input io.Reader = os.Stdin
+
+	if name := c.String(RequestsFlag.Name); len(name) > 0 && name != "-" {
+		var f *os.File
+		f, err = os.Open(name)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		input = f
+	}
+
+	return execRequests(svc.handler, input, os.Stdout)
+}
+
+// execRequests handles the requests one by one as they are decoded from in,
+// the command fails when any of the requests failed.
+func execRequests(handler outgoingHandler, in io.Reader, out io.Writer) (err error) {
+	decoder := json.NewDecoder(in)
+	decoder.UseNumber()
+
+	encoder := json.NewEncoder(out)
+
+	total, failed, internal := 0, 0, 0
+
+	for decoder.More() {
+		req := &bearychat.OutgoingRequest{}
+		if err = decoder.Decode(req); err != nil {
+			return
+		}
+
+		if len(req.TriggerWord) == 0 {
+			req.TriggerWord = bearychat.DetectTriggerWord(handler.Words(), req.Text)
+		}
+
+		status, msg, reqErr := handler.Execute(req)
+
+		result := execResult{Status: status}
+		_, result.Outcome = bearychat.ReplyStatus(reqErr)
+
+		if reqErr != nil && reqErr != bearychat.ErrBreakOnly && reqErr != bearychat.ErrNoContent {
+			failed++
+			if bearychat.KindOf(reqErr) == bearychat.ErrorKindInternal {
+				internal++
+			}
+			result.Error = reqErr.Error()
+		}
+
+		if status != 204 {
+			result.Message = &msg
+		}
+
+		total++
+
+		if err = encoder.Encode(result); err != nil {
+			return
+		}
+	}
+
+	if internal > 0 {
+		return fmt.Errorf("%d of %d requests failed, %d with internal errors", failed, total, internal)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d requests failed", failed, total)
+	}
+
+	return
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestExecRequests(t *testing.T) {
+
+	handler, err := initOutgoingHandler(configuration.ParseString(`{
+		outgoing.deploy {
+			word = "!deploy"
+			drivers = [gogap-auth]
+			gogap-auth.token = "secret"
+		}
+	}`))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	defer handler.Close()
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- execRequests(handler, inR, outW)
+		outW.Close()
+	}()
+
+	lines := bufio.NewScanner(outR)
+
+	next := func(req string) (result execResult) {
+		if _, err := io.WriteString(inW, req+"\n"); err != nil {
+			t.Fatal(err)
+		}
+
+		// the reply is written before the next request is read
+		if !lines.Scan() {
+			t.Fatalf("no reply for %s: %v", req, lines.Err())
+		}
+
+		if err := json.Unmarshal(lines.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	if result := next(`{"token":"secret","text":"!deploy web","user_name":"zeal"}`); result.Outcome != "ok" || result.Status != 200 || len(result.Error) > 0 {
+		t.Errorf("expected an ok result, got %+v", result)
+	}
+
+	if result := next(`{"token":"bad","text":"!deploy web","user_name":"zeal"}`); result.Outcome != "permission_denied" || len(result.Error) == 0 || result.Message == nil {
+		t.Errorf("expected a failed result with the error, got %+v", result)
+	}
+
+	if result := next(`{"token":"secret","text":"!unknown","user_name":"zeal"}`); result.Outcome != "user_error" || len(result.Error) == 0 {
+		t.Errorf("expected a user error result, got %+v", result)
+	}
+
+	inW.Close()
+
+	if err := <-done; err == nil || err.Error() != "2 of 3 requests failed" {
+		t.Errorf("expected the failed requests to be reported, got %v", err)
+	}
+}
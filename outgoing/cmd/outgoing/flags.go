@@ -9,4 +9,41 @@ var (
 		Name:  "config",
 		Usage: "outgoing config file",
 	}
+
+	InputFlag = cli.StringFlag{
+		Name:  "input",
+		Usage: "recorded requests file, - for stdin",
+	}
+
+	RequestsFlag = cli.StringFlag{
+		Name:  "input",
+		Usage: "file of outgoing requests in json, - for stdin",
+	}
+
+	UserFlag = cli.StringFlag{
+		Name:  "user",
+		Value: "zeal",
+		Usage: "user name of the sent messages",
+	}
+
+	ChannelFlag = cli.StringFlag{
+		Name:  "channel",
+		Value: "general",
+		Usage: "channel name of the sent messages",
+	}
+
+	ServiceFlag = cli.StringFlag{
+		Name:  "service",
+		Usage: "name of the service in the services section",
+	}
+
+	TokenFlag = cli.StringFlag{
+		Name:  "token",
+		Usage: "token of the sent messages, defaults to the token of gogap-auth",
+	}
+
+	SubdomainFlag = cli.StringFlag{
+		Name:  "subdomain",
+		Usage: "team subdomain of the sent messages",
+	}
 )
@@ -2,4 +2,5 @@ package main
 
 import (
 	_ "github.com/gogap/bearychat/outgoing/triggers/auth"
+	_ "github.com/gogap/bearychat/outgoing/triggers/ratelimit"
 )
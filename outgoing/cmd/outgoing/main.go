@@ -2,15 +2,23 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-akka/configuration"
 	"github.com/gogap/bearychat"
+	"github.com/gogap/bearychat/internal"
+	"github.com/gogap/bearychat/outgoing/middlewares"
 	"github.com/urfave/cli"
 	"github.com/urfave/negroni"
 )
 
+const (
+	defaultMaxBodySize = 1 << 20
+)
+
 func main() {
 
 	app := cli.NewApp()
@@ -26,19 +34,44 @@ func main() {
 			Action: cmdRun,
 			Flags:  []cli.Flag{ConfigFlag},
 		},
+		{
+			Name:   "replay",
+			Usage:  "replay recorded requests and diff the replies",
+			Action: cmdReplay,
+			Flags:  []cli.Flag{ConfigFlag, InputFlag},
+		},
+		{
+			Name:   "chat",
+			Usage:  "chat with the configured triggers in the terminal",
+			Action: cmdChat,
+			Flags:  []cli.Flag{ConfigFlag, UserFlag, ChannelFlag, TokenFlag, ServiceFlag, SubdomainFlag},
+		},
+		{
+			Name:   "exec",
+			Usage:  "handle outgoing requests read as json from input and print the replies",
+			Action: cmdExec,
+			Flags:  []cli.Flag{ConfigFlag, RequestsFlag, ServiceFlag},
+		},
 	}
 
-	app.Run(os.Args)
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func cmdRun(c *cli.Context) (err error) {
+func loadConfig(c *cli.Context) *configuration.Config {
 	filename := c.String(ConfigFlag.Name)
 
 	if len(filename) == 0 {
 		filename = "bearychat.conf"
 	}
 
-	config := configuration.LoadConfig(filename)
+	return configuration.LoadConfig(filename)
+}
+
+func cmdRun(c *cli.Context) (err error) {
+	config := loadConfig(c)
 
 	httpConfig := config.GetConfig("http")
 
@@ -47,35 +80,111 @@ func cmdRun(c *cli.Context) (err error) {
 		return
 	}
 
-	outgoingConfig := config.GetConfig("outgoing")
+	logger, err := bearychat.NewRequestLogger(config.GetConfig("log"))
+	if err != nil {
+		return
+	}
+
+	var opts []bearychat.OutgoingOption
+	if logger != nil {
+		opts = append(opts, bearychat.LoggerOption(logger))
+	}
+
+	audit, err := bearychat.NewAuditSink(config.GetConfig("audit"))
+	if err != nil {
+		return
+	}
 
-	if outgoingConfig == nil {
-		err = fmt.Errorf("config of outgoing section did not set")
+	if audit != nil {
+		opts = append(opts, bearychat.AuditOption(audit))
+	}
+
+	recorder, err := bearychat.NewRecorder(config.GetConfig("record"))
+	if err != nil {
 		return
 	}
 
-	var out *bearychat.Outgoing
-	out, err = initOutgoing(outgoingConfig)
+	if recorder != nil {
+		opts = append(opts, bearychat.RecorderOption(recorder))
+	}
+
+	if httpConfig.GetBoolean("strict", false) {
+		opts = append(opts, bearychat.StrictOption(true))
+	}
+
+	maxBodySize, err := internal.ByteSize(httpConfig, "max-body-size", defaultMaxBodySize)
+	if err != nil {
+		return
+	}
+
+	if maxBodySize > 0 {
+		opts = append(opts, bearychat.MaxBodySizeOption(maxBodySize))
+	}
+
+	services, err := initServices(config, opts...)
 	if err != nil {
 		return
 	}
 
 	mux := http.NewServeMux()
 
-	path := httpConfig.GetString("path")
+	var closers []io.Closer
+
+	for _, svc := range services {
+		handler := negroni.New()
+
+		if bodyLimit := middlewares.NewBodyLimit(maxBodySize); bodyLimit != nil {
+			handler.Use(bodyLimit)
+		}
+
+		if err = useMiddlewares(handler, svc.config, httpConfig); err != nil {
+			return
+		}
+
+		handler.UseHandlerFunc(svc.handler.HandleHttpRequest)
+
+		mux.Handle(svc.path, handler)
+
+		closers = append(closers, svc.handler)
+	}
+
+	if audit != nil {
+		closers = append(closers, audit)
+	}
+
+	if recorder != nil {
+		closers = append(closers, recorder)
+	}
+
+	metricsPath, metricsHandler, err := newMetricsHandler(httpConfig.GetConfig("metrics"))
+	if err != nil {
+		return
+	}
 
-	mux.HandleFunc(path, out.HandleHttpRequest)
+	if metricsHandler != nil {
+		mux.Handle(metricsPath, metricsHandler)
+	}
+
+	h := &health{}
+
+	mux.HandleFunc("/healthz", h.Healthz)
+	mux.HandleFunc("/readyz", h.Readyz)
 
 	n := negroni.Classic()
 	n.UseHandler(mux)
 
-	err = http.ListenAndServe(httpConfig.GetString("address", ":8080"), n)
+	srv, listen, err := newServer(httpConfig, n)
+	if err != nil {
+		return
+	}
+
+	err = serve(srv, listen, h, httpConfig.GetTimeDuration("drain-timeout", 30*time.Second), closers...)
 
 	return err
 }
 
-func initOutgoing(config *configuration.Config) (*bearychat.Outgoing, error) {
-	outgoing, err := bearychat.NewOutgoing(config)
+func initOutgoing(config *configuration.Config, opts ...bearychat.OutgoingOption) (*bearychat.Outgoing, error) {
+	outgoing, err := bearychat.NewOutgoing(config, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+	"github.com/gogap/bearychat/outgoing/middlewares"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/urfave/negroni"
+)
+
+// newMetricsHandler serves the metrics from a registry of its own, it returns
+// a nil handler unless the metrics section is configured:
+//
+//	metrics {
+//	    path  = /metrics
+//	    allow = { cidrs = ["10.0.0.0/8"] }
+//	}
+func newMetricsHandler(config *configuration.Config) (path string, handler http.Handler, err error) {
+	if config == nil {
+		return
+	}
+
+	reg := prometheus.NewRegistry()
+
+	if err = bearychat.RegisterMetrics(reg); err != nil {
+		return
+	}
+
+	if err = reg.Register(collectors.NewGoCollector()); err != nil {
+		return
+	}
+
+	if err = reg.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})); err != nil {
+		return
+	}
+
+	access, err := middlewares.NewAccess(config.GetConfig("allow"))
+	if err != nil {
+		return
+	}
+
+	n := negroni.New()
+
+	if access != nil {
+		n.Use(access)
+	}
+
+	n.UseHandler(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+	return config.GetString("path", "/metrics"), n, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat/outgoing/middlewares"
+	"github.com/urfave/negroni"
+)
+
+// useMiddlewares adds the middlewares configured in the first config which
+// has the section, so services inherit the settings of the http section.
+func useMiddlewares(n *negroni.Negroni, configs ...*configuration.Config) error {
+
+	access, err := middlewares.NewAccess(section("allow", configs...))
+	if err != nil {
+		return err
+	}
+
+	if access != nil {
+		n.Use(access)
+	}
+
+	signature, err := middlewares.NewSignature(section("signature", configs...))
+	if err != nil {
+		return err
+	}
+
+	if signature != nil {
+		n.Use(signature)
+	}
+
+	return nil
+}
+
+func section(name string, configs ...*configuration.Config) *configuration.Config {
+	for i := 0; i < len(configs); i++ {
+		if conf := configs[i].GetConfig(name); conf != nil {
+			return conf
+		}
+	}
+
+	return nil
+}
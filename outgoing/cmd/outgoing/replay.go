@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogap/bearychat"
+	"github.com/urfave/cli"
+)
+
+// cmdReplay feeds recorded requests through the configured services and
+// prints the replies which differ from the recorded ones.
+func cmdReplay(c *cli.Context) (err error) {
+	config := loadConfig(c)
+
+	clock := &replayClock{}
+
+	// the drivers see the time of each recording, e.g. for auth windows,
+	// rate limits and confirmation expiry
+	services, err := initServices(config, bearychat.ClockOption(clock.Now))
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		for _, svc := range services {
+			svc.handler.Close()
+		}
+	}()
+
+	handlers := make(map[string]outgoingHandler)
+	for _, svc := range services {
+		handlers[svc.name] = svc.handler
+	}
+
+	var input io.Reader = os.Stdin
+
+	if name := c.String(InputFlag.Name); len(name) > 0 && name != "-" {
+		var f *os.File
+		f, err = os.Open(name)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		input = f
+	}
+
+	recs, err := bearychat.ReadRecordings(input)
+	if err != nil {
+		return
+	}
+
+	changed := replay(handlers, recs, clock, os.Stdout)
+
+	fmt.Printf("%d replayed, %d changed\n", len(recs), changed)
+
+	if changed > 0 {
+		return fmt.Errorf("%d of %d replies changed", changed, len(recs))
+	}
+
+	return nil
+}
+
+// replay handles the recordings with the clock set to the time of each one
+// and writes the replies which differ, it returns the number of them.
+func replay(handlers map[string]outgoingHandler, recs []*bearychat.Recording, clock *replayClock, out io.Writer) (changed int) {
+	for _, rec := range recs {
+		handler, exist := handlers[rec.Service]
+		if !exist {
+			changed++
+			fmt.Fprintf(out, "request %s: service %q not configured\n\n", rec.ID, rec.Service)
+			continue
+		}
+
+		req, err := rec.DecodeRequest()
+		if err != nil {
+			changed++
+			fmt.Fprintf(out, "request %s: %s\n\n", rec.ID, err.Error())
+			continue
+		}
+
+		clock.Set(rec.Time)
+
+		if rec.TokenRedacted {
+			req.Token = handler.Token(req)
+		}
+
+		status, msg := handler.Reply(req)
+
+		recorded := formatReply(rec.Status, rec.Message)
+		replayed := formatReply(status, msg)
+
+		if recorded == replayed {
+			continue
+		}
+
+		changed++
+
+		fmt.Fprintf(out, "request %s: @%s #%s %s\n", rec.ID, rec.Request.UserName, rec.Request.ChannelName, rec.Request.Text)
+		fmt.Fprintln(out, diffLines(recorded, replayed))
+	}
+
+	return
+}
+
+// replayClock is the clock of the replayed services, it is set to the time
+// of the recording being replayed.
+type replayClock struct {
+	now time.Time
+
+	sync.Mutex
+}
+
+func (p *replayClock) Now() time.Time {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.now.IsZero() {
+		return time.Now()
+	}
+
+	return p.now
+}
+
+func (p *replayClock) Set(now time.Time) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.now = now
+}
+
+func formatReply(status int, msg bearychat.Message) string {
+	data, _ := json.MarshalIndent(struct {
+		Status  int               `json:"status"`
+		Message bearychat.Message `json:"message"`
+	}{status, msg}, "", "  ")
+
+	return string(data)
+}
+
+// diffLines returns a line diff of a and b, removed lines are prefixed by -
+// and added lines by +.
+func diffLines(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, "  "+x[i])
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+x[i])
+			i++
+		default:
+			lines = append(lines, "+ "+y[j])
+			j++
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
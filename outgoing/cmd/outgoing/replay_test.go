@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+func TestDiffLines(t *testing.T) {
+
+	cases := []struct {
+		a, b     string
+		expected string
+	}{
+		{"a\nb", "a\nb", "  a\n  b"},
+		{"a\nb\nc", "a\nc", "  a\n- b\n  c"},
+		{"a\nc", "a\nb\nc", "  a\n+ b\n  c"},
+		{"a\nb", "a\nx", "  a\n- b\n+ x"},
+		{"", "x", "- \n+ x"},
+	}
+
+	for _, c := range cases {
+		if diff := diffLines(c.a, c.b); diff != c.expected {
+			t.Errorf("diff of %q and %q: expected %q, got %q", c.a, c.b, c.expected, diff)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+
+	clock := &replayClock{}
+
+	services, err := initServices(configuration.ParseString(`{
+		outgoing.deploy {
+			word = "!deploy"
+			drivers = [gogap-auth, gogap-ratelimit]
+			gogap-auth {
+				token  = "secret"
+				window = 1m
+			}
+			gogap-ratelimit {
+				key    = [user]
+				burst  = 1
+				refill = 1h
+			}
+		}
+	}`), bearychat.ClockOption(clock.Now))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	defer services[0].handler.Close()
+
+	handlers := map[string]outgoingHandler{"": services[0].handler}
+
+	recorded := time.Now().Add(-24 * time.Hour)
+
+	recording := func(id string, at time.Time, text string) *bearychat.Recording {
+		return &bearychat.Recording{
+			Time:          at,
+			ID:            id,
+			Request:       bearychat.OutgoingRequest{Token: "******", Timestamp: at.Unix(), Text: "!deploy web", TriggerWord: "!deploy", UserName: "zeal"},
+			TokenRedacted: true,
+			Status:        200,
+			Message:       bearychat.Message{Text: text},
+		}
+	}
+
+	out := bytes.NewBuffer(nil)
+
+	// replayed with the time of the recordings the requests are within the
+	// auth window and the bucket is refilled between them
+	recs := []*bearychat.Recording{
+		recording("first", recorded, ""),
+		recording("second", recorded.Add(2*time.Hour), ""),
+	}
+
+	if changed := replay(handlers, recs, clock, out); changed != 0 {
+		t.Errorf("recordings should replay unchanged, got %d changed:\n%s", changed, out)
+	}
+
+	out.Reset()
+
+	recs = []*bearychat.Recording{
+		recording("third", recorded.Add(4*time.Hour), "deployed"),
+		{ID: "fourth", Service: "unknown"},
+	}
+
+	if changed := replay(handlers, recs, clock, out); changed != 2 || !strings.Contains(out.String(), "request third: @zeal") || !strings.Contains(out.String(), `-     "text": "deployed",`) || !strings.Contains(out.String(), `service "unknown" not configured`) {
+		t.Errorf("changed replies should be reported, got %d:\n%s", changed, out)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+// newServer creates the http server and its listener by the http section,
+// the address could be "unix:/path/to/outgoing.sock" for sidecar deployments.
+func newServer(config *configuration.Config, handler http.Handler) (srv *http.Server, listen func() error, err error) {
+
+	srv = &http.Server{
+		Handler:           handler,
+		ReadTimeout:       config.GetTimeDuration("read-timeout", 30*time.Second),
+		ReadHeaderTimeout: config.GetTimeDuration("read-header-timeout", 10*time.Second),
+		WriteTimeout:      config.GetTimeDuration("write-timeout", 0),
+		IdleTimeout:       config.GetTimeDuration("idle-timeout", 120*time.Second),
+	}
+
+	srv.TLSConfig, err = newTLSConfig(config.GetConfig("tls"))
+	if err != nil {
+		return
+	}
+
+	address := config.GetString("address", ":8080")
+
+	var ln net.Listener
+
+	if strings.HasPrefix(address, "unix:") {
+		ln, err = listenUnix(strings.TrimPrefix(address, "unix:"), config.GetString("socket-mode", "0660"))
+	} else {
+		srv.Addr = address
+		ln, err = net.Listen("tcp", address)
+	}
+
+	if err != nil {
+		return
+	}
+
+	listen = func() error {
+		if srv.TLSConfig != nil {
+			return srv.ServeTLS(ln, "", "")
+		}
+		return srv.Serve(ln)
+	}
+
+	return
+}
+
+func listenUnix(path string, mode string) (net.Listener, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("bad http.socket-mode: %s", mode)
+	}
+
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.Chmod(path, os.FileMode(perm)); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	return ln, nil
+}
+
+type health struct {
+	ready int32
+}
+
+func (p *health) SetReady(ready bool) {
+	if ready {
+		atomic.StoreInt32(&p.ready, 1)
+	} else {
+		atomic.StoreInt32(&p.ready, 0)
+	}
+}
+
+func (p *health) Healthz(rw http.ResponseWriter, r *http.Request) {
+	rw.Write([]byte("ok"))
+}
+
+func (p *health) Readyz(rw http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&p.ready) == 0 {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		rw.Write([]byte("not ready"))
+		return
+	}
+
+	rw.Write([]byte("ready"))
+}
+
+// serve runs srv until SIGTERM or SIGINT, then stops accepting requests and
+// waits up to drain for in-flight ones before closing the closers, which
+// terminate the commands still running.
+func serve(srv *http.Server, listen func() error, h *health, drain time.Duration, closers ...io.Closer) error {
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	return serveUntil(srv, listen, h, drain, sigCh, closers...)
+}
+
+// serveUntil is serve with the signals received from sigCh.
+func serveUntil(srv *http.Server, listen func() error, h *health, drain time.Duration, sigCh <-chan os.Signal, closers ...io.Closer) error {
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- listen()
+	}()
+
+	h.SetReady(true)
+
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-sigCh:
+		log.Printf("[outgoing] received %s, draining requests for up to %s", sig, drain)
+	}
+
+	h.SetReady(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+
+	shutdownErr := srv.Shutdown(ctx)
+
+	for i := 0; i < len(closers); i++ {
+		if err := closers[i].Close(); err != nil {
+			log.Printf("[outgoing] close error: %s", err.Error())
+		}
+	}
+
+	if shutdownErr != nil {
+		log.Printf("[outgoing] drain timeout exceeded, closing remaining connections")
+		srv.Close()
+	}
+
+	return nil
+}
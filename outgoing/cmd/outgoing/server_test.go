@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+type closeFunc func() error
+
+func (p closeFunc) Close() error {
+	return p()
+}
+
+func unixClient(path string) *http.Client {
+	return &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+func TestServeDrain(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported on windows")
+	}
+
+	sock := filepath.Join(t.TempDir(), "outgoing.sock")
+
+	started := make(chan struct{})
+	var finished int32
+
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+	})
+
+	srv, listen, err := newServer(configuration.ParseString(`{ address = "unix:`+sock+`" }`), handler)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	h := &health{}
+	sigCh := make(chan os.Signal, 1)
+
+	var closedAfterDrain int32
+	closer := closeFunc(func() error {
+		atomic.StoreInt32(&closedAfterDrain, atomic.LoadInt32(&finished))
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- serveUntil(srv, listen, h, time.Second, sigCh, closer)
+	}()
+
+	go unixClient(sock).Get("http://outgoing/")
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Error("request should be served")
+		return
+	}
+
+	rw := httptest.NewRecorder()
+	h.Readyz(rw, nil)
+	if rw.Code != http.StatusOK {
+		t.Errorf("server should be ready while serving, got %d", rw.Code)
+	}
+
+	sigCh <- syscall.SIGTERM
+
+	if err = <-done; err != nil {
+		t.Error(err)
+	}
+
+	if atomic.LoadInt32(&closedAfterDrain) != 1 {
+		t.Error("in-flight request should finish before the closers are closed")
+	}
+
+	rw = httptest.NewRecorder()
+	h.Readyz(rw, nil)
+	if rw.Code != http.StatusServiceUnavailable {
+		t.Errorf("server should not be ready after the signal, got %d", rw.Code)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported on windows")
+	}
+
+	sock := filepath.Join(t.TempDir(), "outgoing.sock")
+
+	srv, _, err := newServer(configuration.ParseString(`{
+		address      = "unix:`+sock+`"
+		socket-mode  = "0600"
+		read-timeout = 5s
+		idle-timeout = 1m
+	}`), http.NotFoundHandler())
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	defer srv.Close()
+
+	if srv.ReadTimeout != 5*time.Second || srv.IdleTimeout != time.Minute || srv.ReadHeaderTimeout != 10*time.Second {
+		t.Errorf("bad timeouts: %s %s %s", srv.ReadTimeout, srv.IdleTimeout, srv.ReadHeaderTimeout)
+	}
+
+	fi, err := os.Stat(sock)
+	if err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("socket should be created with mode 0600: %v %v", fi, err)
+	}
+
+	if _, _, err = newServer(configuration.ParseString(`{ address = "unix:`+sock+`.2", socket-mode = "rw" }`), http.NotFoundHandler()); err == nil {
+		t.Error("bad socket mode should be rejected")
+	}
+}
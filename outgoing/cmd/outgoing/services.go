@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+type outgoingHandler interface {
+	HandleHttpRequest(rw http.ResponseWriter, req *http.Request)
+	Reply(req *bearychat.OutgoingRequest) (int, bearychat.Message)
+	Execute(req *bearychat.OutgoingRequest) (int, bearychat.Message, error)
+	Token(req *bearychat.OutgoingRequest) string
+	Words() []string
+	Close() error
+}
+
+type service struct {
+	name    string
+	path    string
+	config  *configuration.Config
+	handler outgoingHandler
+}
+
+// initServices creates the services mounted on their own paths:
+//
+//	services {
+//	    ops {
+//	        path = "/ops"
+//	        allow { ... }       // middlewares, defaults to the http section
+//	        signature { ... }
+//	        error { ... }       // error template of the service
+//	        outgoing { ... }
+//	        teams { ... }
+//	    }
+//	}
+//
+// The top level outgoing section is still served on http.path.
+func initServices(config *configuration.Config, opts ...bearychat.OutgoingOption) ([]*service, error) {
+
+	var services []*service
+
+	if config.GetConfig("outgoing") != nil {
+		svc, err := initService("", config.GetString("http.path"), config, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		services = append(services, svc)
+	}
+
+	if servicesConfig := config.GetConfig("services"); servicesConfig != nil {
+		for _, name := range servicesConfig.Root().GetObject().GetKeys() {
+			serviceConfig := servicesConfig.GetConfig(name)
+
+			svc, err := initService(name, serviceConfig.GetString("path", "/"+name), serviceConfig, append(opts, bearychat.ServiceOption(name))...)
+			if err != nil {
+				return nil, err
+			}
+
+			services = append(services, svc)
+		}
+	}
+
+	if len(services) == 0 {
+		return nil, fmt.Errorf("config of outgoing or services section did not set")
+	}
+
+	paths := make(map[string]string)
+
+	for _, svc := range services {
+		if other, exist := paths[svc.path]; exist {
+			return nil, fmt.Errorf("path %s of service %s is already used by service %s", svc.path, svc.name, other)
+		}
+
+		paths[svc.path] = svc.name
+	}
+
+	return services, nil
+}
+
+// findService returns the service of name, an empty name selects the top
+// level outgoing section, or the only service.
+func findService(services []*service, name string) (*service, error) {
+	for _, svc := range services {
+		if svc.name == name {
+			return svc, nil
+		}
+	}
+
+	if len(name) == 0 && len(services) == 1 {
+		return services[0], nil
+	}
+
+	if len(name) == 0 {
+		return nil, fmt.Errorf("several services are configured, please select one")
+	}
+
+	return nil, fmt.Errorf("service %s not configured", name)
+}
+
+func initService(name, path string, config *configuration.Config, opts ...bearychat.OutgoingOption) (*service, error) {
+
+	errorTemplate, err := bearychat.NewErrorTemplate(config.GetConfig("error"))
+	if err != nil {
+		return nil, err
+	}
+
+	if errorTemplate != nil {
+		opts = append(opts, bearychat.ErrorTemplateOption(errorTemplate))
+	}
+
+	handler, err := initOutgoingHandler(config, opts...)
+	if err != nil {
+		if len(name) > 0 {
+			err = fmt.Errorf("service %s: %s", name, err.Error())
+		}
+		return nil, err
+	}
+
+	return &service{
+		name:    name,
+		path:    path,
+		config:  config,
+		handler: handler,
+	}, nil
+}
+
+// initOutgoingHandler creates the Outgoing of the outgoing section, when the
+// teams section is set, requests are routed by their subdomain:
+//
+//	teams {
+//	    acme {
+//	        subdomain = "acme"   // defaults to the key
+//	        outgoing { ... }
+//	    }
+//	}
+func initOutgoingHandler(config *configuration.Config, opts ...bearychat.OutgoingOption) (outgoingHandler, error) {
+
+	outgoingConfig := config.GetConfig("outgoing")
+
+	if outgoingConfig == nil {
+		return nil, fmt.Errorf("config of outgoing section did not set")
+	}
+
+	teamsConfig := config.GetConfig("teams")
+
+	if teamsConfig == nil {
+		return initOutgoing(outgoingConfig, opts...)
+	}
+
+	fallback, err := initOutgoing(outgoingConfig, append(opts, bearychat.TeamOption("default"))...)
+	if err != nil {
+		return nil, err
+	}
+
+	router := bearychat.NewTeamRouter(fallback)
+
+	for _, team := range teamsConfig.Root().GetObject().GetKeys() {
+		teamConfig := teamsConfig.GetConfig(team)
+
+		teamOutgoingConfig := teamConfig.GetConfig("outgoing")
+		if teamOutgoingConfig == nil {
+			return nil, fmt.Errorf("config of teams.%s.outgoing section did not set", team)
+		}
+
+		out, err := initOutgoing(teamOutgoingConfig, append(opts, bearychat.TeamOption(team))...)
+		if err != nil {
+			return nil, err
+		}
+
+		router.AddTeam(teamConfig.GetString("subdomain", team), out)
+	}
+
+	return router, nil
+}
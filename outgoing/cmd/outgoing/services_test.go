@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+func TestInitServices(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		http.path = "/triggers"
+
+		outgoing {
+			hello {
+				word = "!hello"
+				drivers = [gogap-auth]
+				gogap-auth.token = "hello-token"
+			}
+		}
+
+		services {
+			ops {
+				outgoing {
+					cmd {
+						word = "!cmd"
+						drivers = [gogap-auth]
+						gogap-auth.token = "cmd-token"
+					}
+				}
+
+				teams {
+					acme.outgoing {
+						cmd {
+							word = "!acme"
+							drivers = [gogap-auth]
+							gogap-auth.token = "acme-token"
+						}
+					}
+				}
+			}
+
+			dev {
+				path = "/development"
+				outgoing {
+					cmd {
+						word = "!dev"
+						drivers = [gogap-auth]
+						gogap-auth.token = "dev-token"
+					}
+				}
+			}
+		}
+	}`)
+
+	services, err := initServices(config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	var paths []string
+	for _, svc := range services {
+		paths = append(paths, svc.name+"="+svc.path)
+	}
+
+	if strings.Join(paths, " ") != "=/triggers ops=/ops dev=/development" {
+		t.Errorf("bad services: %v", paths)
+	}
+
+	if _, ok := services[1].handler.(*bearychat.TeamRouter); !ok {
+		t.Errorf("service with teams should be routed by subdomain, got %T", services[1].handler)
+	}
+
+	if svc, err := findService(services, "dev"); err != nil || svc.path != "/development" {
+		t.Errorf("service dev should be found: %v", err)
+	}
+
+	if svc, err := findService(services, ""); err != nil || svc.path != "/triggers" {
+		t.Errorf("empty name should select the top level outgoing section: %v", err)
+	}
+
+	if _, err = findService(services[1:], ""); err == nil {
+		t.Error("empty name should be ambiguous with several services")
+	}
+
+	if svc, err := findService(services[2:], ""); err != nil || svc.name != "dev" {
+		t.Errorf("the only service should be selected by default: %v", err)
+	}
+
+	if _, err = findService(services, "unknown"); err == nil {
+		t.Error("unknown service should not be found")
+	}
+}
+
+func TestInitServicesErrors(t *testing.T) {
+
+	cases := map[string]string{
+		"no services": `{ http.path = "/" }`,
+		"duplicated path": `{
+			services {
+				a { path = "/x", outgoing.t { word = "!a", drivers = [gogap-auth], gogap-auth.token = "a" } }
+				b { path = "/x", outgoing.t { word = "!b", drivers = [gogap-auth], gogap-auth.token = "b" } }
+			}
+		}`,
+		"no outgoing": `{ services.a.path = "/a" }`,
+	}
+
+	for name, conf := range cases {
+		if _, err := initServices(configuration.ParseString(conf)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+// certReloader reloads the key pair once the cert or key file is modified,
+// files are checked at most once per interval.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+
+	sync.Mutex
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (p *certReloader) load() error {
+	modTime, err := p.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(p.certFile, p.keyFile)
+	if err != nil {
+		return err
+	}
+
+	p.cert = &cert
+	p.modTime = modTime
+
+	return nil
+}
+
+func (p *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range []string{p.certFile, p.keyFile} {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+func (p *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(p.checkedAt) < p.interval {
+		return p.cert, nil
+	}
+
+	p.checkedAt = now
+
+	if modTime, err := p.latestModTime(); err == nil && modTime.After(p.modTime) {
+		if err = p.load(); err != nil {
+			log.Printf("[outgoing] reload tls certificate failed, keep using the old one: %s", err.Error())
+		} else {
+			log.Printf("[outgoing] tls certificate reloaded")
+		}
+	}
+
+	return p.cert, nil
+}
+
+// newTLSConfig creates the tls config by the http.tls section:
+//
+//	tls {
+//	    cert            = /etc/outgoing/cert.pem
+//	    key             = /etc/outgoing/key.pem
+//	    reload-interval = 10s
+//	    client-ca       = /etc/outgoing/ca.pem
+//	    client-auth     = require   // require or verify-if-given
+//	}
+func newTLSConfig(config *configuration.Config) (*tls.Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	certFile := config.GetString("cert")
+	keyFile := config.GetString("key")
+
+	if len(certFile) == 0 || len(keyFile) == 0 {
+		return nil, errors.New("http.tls.cert and http.tls.key should both be set")
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile, config.GetTimeDuration("reload-interval", 10*time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if caFile := config.GetString("client-ca"); len(caFile) > 0 {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", caFile)
+		}
+
+		tlsConfig.ClientCAs = pool
+
+		switch auth := config.GetString("client-auth", "require"); auth {
+		case "require":
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		case "verify-if-given":
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		default:
+			return nil, fmt.Errorf("unknown http.tls.client-auth: %s", auth)
+		}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,124 @@
+package middlewares
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-akka/configuration"
+)
+
+// Access only lets requests from the allowed networks through, the client
+// address is taken from X-Forwarded-For only when the peer is a trusted proxy.
+//
+//	allow {
+//	    cidrs           = ["10.0.0.0/8", "203.0.113.7"]
+//	    trusted-proxies = ["127.0.0.1"]
+//	}
+type Access struct {
+	allowed []*net.IPNet
+	proxies []*net.IPNet
+}
+
+func NewAccess(config *configuration.Config) (*Access, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	allowed, err := parseNets(config.GetStringList("cidrs"))
+	if err != nil {
+		return nil, err
+	}
+
+	proxies, err := parseNets(config.GetStringList("trusted-proxies"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Access{
+		allowed: allowed,
+		proxies: proxies,
+	}, nil
+}
+
+func (p *Access) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+	ip := p.ClientIP(r)
+
+	if ip == nil || !contains(p.allowed, ip) {
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	next(rw, r)
+}
+
+func (p *Access) ClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !contains(p.proxies, ip) || len(r.Header["X-Forwarded-For"]) == 0 {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			return nil
+		}
+
+		if !contains(p.proxies, hop) {
+			return hop
+		}
+
+		ip = hop
+	}
+
+	return ip
+}
+
+func parseNets(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("bad ip address: %s", cidr)
+			}
+
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for i := 0; i < len(nets); i++ {
+		if nets[i].Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+)
+
+// BodyLimit limits the size of request bodies, it should be the outermost
+// middleware so that the ones reading the body, e.g. Signature, are bounded
+// too. Reading over the limit fails with *http.MaxBytesError.
+type BodyLimit struct {
+	max int64
+}
+
+func NewBodyLimit(max int64) *BodyLimit {
+	if max <= 0 {
+		return nil
+	}
+
+	return &BodyLimit{max: max}
+}
+
+func (p *BodyLimit) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if r.ContentLength > p.max {
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, p.max)
+
+	next(rw, r)
+}
@@ -0,0 +1,122 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+	"github.com/urfave/negroni"
+)
+
+func ok(rw http.ResponseWriter, r *http.Request) {
+	rw.WriteHeader(http.StatusOK)
+}
+
+func TestAccess(t *testing.T) {
+
+	access, err := NewAccess(configuration.ParseString(`{
+		cidrs = ["10.0.0.0/8", "203.0.113.7"]
+		trusted-proxies = ["127.0.0.1"]
+	}`))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cases := []struct {
+		remote    string
+		forwarded string
+		status    int
+	}{
+		{"10.1.2.3:1234", "", http.StatusOK},
+		{"203.0.113.7:1234", "", http.StatusOK},
+		{"192.168.1.1:1234", "", http.StatusForbidden},
+		{"192.168.1.1:1234", "10.1.2.3", http.StatusForbidden},
+		{"127.0.0.1:1234", "10.1.2.3", http.StatusOK},
+		{"127.0.0.1:1234", "10.1.2.3, 192.168.1.1", http.StatusForbidden},
+		{"127.0.0.1:1234", "192.168.1.1, 10.1.2.3, 127.0.0.1", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", "/triggers", nil)
+		req.RemoteAddr = c.remote
+		if len(c.forwarded) > 0 {
+			req.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+
+		rw := httptest.NewRecorder()
+		access.ServeHTTP(rw, req, ok)
+
+		if rw.Code != c.status {
+			t.Errorf("remote %s forwarded for %q: expected %d, got %d", c.remote, c.forwarded, c.status, rw.Code)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+
+	signature, err := NewSignature(configuration.ParseString(`{ secret = "s3cret" }`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	body := `{"text":"!cmd ping"}`
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+	sum := hex.EncodeToString(mac.Sum(nil))
+
+	for sig, status := range map[string]int{
+		sum:             http.StatusOK,
+		"sha256=" + sum: http.StatusOK,
+		"deadbeef":      http.StatusUnauthorized,
+		"":              http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("POST", "/triggers", strings.NewReader(body))
+		req.Header.Set("X-Signature", sig)
+
+		rw := httptest.NewRecorder()
+		signature.ServeHTTP(rw, req, ok)
+
+		if rw.Code != status {
+			t.Errorf("signature %q: expected %d, got %d", sig, status, rw.Code)
+		}
+	}
+}
+
+func TestSignatureBodyLimit(t *testing.T) {
+
+	signature, err := NewSignature(configuration.ParseString(`{ secret = "s3cret" }`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	n := negroni.New(NewBodyLimit(16), signature)
+	n.UseHandlerFunc(ok)
+
+	body := strings.Repeat("x", 64)
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+
+	for _, contentLength := range []int64{int64(len(body)), -1} {
+		req := httptest.NewRequest("POST", "/triggers", strings.NewReader(body))
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+		req.ContentLength = contentLength
+
+		rw := httptest.NewRecorder()
+		n.ServeHTTP(rw, req)
+
+		if rw.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("oversized signed request with content length %d: expected 413, got %d", contentLength, rw.Code)
+		}
+	}
+}
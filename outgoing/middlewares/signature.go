@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-akka/configuration"
+)
+
+// Signature verifies the HMAC of the raw request body, for setups where a
+// proxy in front of the service signs the requests. The body is read whole,
+// so BodyLimit should be used before it.
+//
+//	signature {
+//	    secret     = "..."   // or secret-env = "OUTGOING_SIGNATURE_SECRET"
+//	    header     = "X-Signature"
+//	    algorithm  = sha256  // sha1, sha256 or sha512
+//	}
+//
+// The header value is the hex digest, optionally prefixed by "<algorithm>=".
+type Signature struct {
+	secret    []byte
+	header    string
+	algorithm string
+	hash      func() hash.Hash
+}
+
+func NewSignature(config *configuration.Config) (*Signature, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	secret := config.GetString("secret")
+	if env := config.GetString("secret-env"); len(env) > 0 {
+		secret = os.Getenv(env)
+	}
+
+	if len(secret) == 0 {
+		return nil, errors.New("secret of http signature is empty")
+	}
+
+	sig := &Signature{
+		secret:    []byte(secret),
+		header:    config.GetString("header", "X-Signature"),
+		algorithm: config.GetString("algorithm", "sha256"),
+	}
+
+	switch sig.algorithm {
+	case "sha1":
+		sig.hash = sha1.New
+	case "sha256":
+		sig.hash = sha256.New
+	case "sha512":
+		sig.hash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm: %s", sig.algorithm)
+	}
+
+	return sig, nil
+}
+
+func (p *Signature) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(r.Header.Get(p.header), p.algorithm+"="))
+	if err != nil || len(expected) == 0 {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			rw.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	mac := hmac.New(p.hash, p.secret)
+	mac.Write(body)
+
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	next(rw, r)
+}
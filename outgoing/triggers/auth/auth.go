@@ -1,15 +1,31 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/go-akka/configuration"
 	"github.com/gogap/bearychat"
 )
 
+var (
+	ErrNoToken = errors.New("gogap-auth: no token configured, every request would be rejected")
+)
+
+type token struct {
+	value   string
+	expires time.Time
+}
+
 type Auth struct {
-	word  string
-	token string
+	word   string
+	tokens []token
+	window time.Duration
 }
 
 func init() {
@@ -17,23 +33,148 @@ func init() {
 }
 
 func NewAuth(word string, config *configuration.Config) (bearychat.Trigger, error) {
-	return &Auth{
-		word:  word,
-		token: config.GetString("token"),
-	}, nil
+
+	auth := &Auth{
+		word: word,
+	}
+
+	if config == nil {
+		return nil, ErrNoToken
+	}
+
+	if value := config.GetString("token"); len(value) > 0 {
+		auth.tokens = append(auth.tokens, token{value: value})
+	}
+
+	tokensConfig := config.GetConfig("tokens")
+
+	if tokensConfig != nil {
+		keys := tokensConfig.Root().GetObject().GetKeys()
+		for _, key := range keys {
+			t, err := loadToken(key, tokensConfig.GetConfig(key))
+			if err != nil {
+				return nil, err
+			}
+
+			auth.tokens = append(auth.tokens, t)
+		}
+	}
+
+	if len(auth.tokens) == 0 {
+		return nil, ErrNoToken
+	}
+
+	auth.window = config.GetTimeDuration("window", 0)
+
+	return auth, nil
+}
+
+func loadToken(name string, config *configuration.Config) (t token, err error) {
+
+	if config == nil {
+		err = fmt.Errorf("gogap-auth: config of token %s is empty", name)
+		return
+	}
+
+	value := config.GetString("value")
+
+	if env := config.GetString("env"); len(env) > 0 {
+		value = os.Getenv(env)
+	}
+
+	if file := config.GetString("file"); len(file) > 0 {
+		var data []byte
+		data, err = ioutil.ReadFile(file)
+		if err != nil {
+			return
+		}
+		value = string(data)
+	}
+
+	value = strings.TrimSpace(value)
+
+	if len(value) == 0 {
+		err = fmt.Errorf("gogap-auth: value of token %s is empty", name)
+		return
+	}
+
+	t.value = value
+
+	if expires := config.GetString("expires"); len(expires) > 0 {
+		t.expires, err = parseTime(expires)
+		if err != nil {
+			err = fmt.Errorf("gogap-auth: bad expires of token %s: %s", name, err.Error())
+			return
+		}
+	}
+
+	return
+}
+
+func parseTime(str string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+
+	return time.Parse("2006-01-02", str)
 }
 
 func (p *Auth) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message) (err error) {
 
 	if req.TriggerWord != p.word {
-		err = errors.New("bad request trigger word in gogap-auth")
+		err = bearychat.PermissionDenied(errors.New("bad request trigger word in gogap-auth"))
 		return
 	}
 
-	if req.Token != p.token {
-		err = errors.New("error auth token")
+	now := req.Now()
+
+	if !p.validToken(req.Token, now) {
+		err = bearychat.PermissionDenied(errors.New("error auth token"))
+		return
+	}
+
+	if p.window > 0 && !p.fresh(req.Time(), now) {
+		err = bearychat.PermissionDenied(errors.New("request timestamp is out of the allowed window"))
 		return
 	}
 
 	return
 }
+
+// Token returns the first configured token which has not expired.
+func (p *Auth) Token(now time.Time) string {
+	for i := 0; i < len(p.tokens); i++ {
+		if p.tokens[i].expires.IsZero() || now.Before(p.tokens[i].expires) {
+			return p.tokens[i].value
+		}
+	}
+
+	return ""
+}
+
+func (p *Auth) validToken(value string, now time.Time) bool {
+	valid := 0
+
+	for i := 0; i < len(p.tokens); i++ {
+		if !p.tokens[i].expires.IsZero() && now.After(p.tokens[i].expires) {
+			continue
+		}
+
+		valid |= subtle.ConstantTimeCompare([]byte(value), []byte(p.tokens[i].value))
+	}
+
+	return valid == 1
+}
+
+func (p *Auth) fresh(t time.Time, now time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+
+	diff := now.Sub(t)
+	if diff < 0 {
+		diff = -diff
+	}
+
+	return diff <= p.window
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+func TestAuthTokens(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "gogap-auth")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	secretFile := filepath.Join(dir, "token")
+	if err = ioutil.WriteFile(secretFile, []byte("file-token\n"), 0600); err != nil {
+		t.Error(err)
+		return
+	}
+
+	os.Setenv("GOGAP_AUTH_TEST_TOKEN", "env-token")
+	defer os.Unsetenv("GOGAP_AUTH_TEST_TOKEN")
+
+	auth, err := NewAuth("!cmd", configuration.ParseString(`{
+		token = "plain-token"
+		tokens {
+			env     { env = "GOGAP_AUTH_TEST_TOKEN" }
+			file    { file = "`+secretFile+`" }
+			expired { value = "expired-token", expires = "2000-01-01T00:00:00Z" }
+		}
+		window = 5m
+	}`))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	now := time.Now().Unix()
+
+	cases := []struct {
+		token string
+		ts    int64
+		ok    bool
+	}{
+		{"plain-token", now, true},
+		{"env-token", now, true},
+		{"file-token", now, true},
+		{"file-token", now * 1000, true},
+		{"expired-token", now, false},
+		{"unknown-token", now, false},
+		{"plain-token", now - 3600, false},
+	}
+
+	for _, c := range cases {
+		req := &bearychat.OutgoingRequest{TriggerWord: "!cmd", Token: c.token, Timestamp: c.ts}
+		err = auth.Handle(req, &bearychat.Message{})
+		if (err == nil) != c.ok {
+			t.Errorf("token %s with ts %d: expected ok=%v, got err: %v", c.token, c.ts, c.ok, err)
+		}
+	}
+}
+
+func TestAuthNoToken(t *testing.T) {
+
+	if _, err := NewAuth("!cmd", nil); err != ErrNoToken {
+		t.Errorf("missing config should fail with ErrNoToken, got: %v", err)
+	}
+
+	if _, err := NewAuth("!cmd", configuration.ParseString(`{ window = 5m }`)); err != ErrNoToken {
+		t.Errorf("config without tokens should fail with ErrNoToken, got: %v", err)
+	}
+}
+
+func TestAuthProvidesToken(t *testing.T) {
+
+	outgoing, err := bearychat.NewOutgoing(configuration.ParseString(`{
+		cmd {
+			word = "!cmd"
+			commands = [deploy]
+			drivers = [gogap-auth]
+			gogap-auth.tokens {
+				expired { value = "expired-token", expires = "2000-01-01" }
+				current { value = "current-token" }
+			}
+		}
+	}`))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if token := outgoing.Token(&bearychat.OutgoingRequest{TriggerWord: "!cmd"}); token != "current-token" {
+		t.Errorf("expected the token which has not expired, got %q", token)
+	}
+
+	if token := outgoing.Token(&bearychat.OutgoingRequest{TriggerWord: "!other"}); len(token) > 0 {
+		t.Errorf("unknown word should have no token, got %q", token)
+	}
+}
@@ -40,7 +40,7 @@ func NewChannelFilter(word string, config *configuration.Config) (bearychat.Trig
 func (p *ChannelFilter) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message) (err error) {
 
 	if !p.channels[req.ChannelName] {
-		err = errors.New("gogap-channel-filter: Illegal channel.")
+		err = bearychat.PermissionDenied(errors.New("gogap-channel-filter: Illegal channel."))
 		return
 	}
 
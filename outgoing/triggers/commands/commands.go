@@ -3,7 +3,6 @@ package commands
 import (
 	"bytes"
 	"errors"
-	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -14,8 +13,12 @@ import (
 )
 
 type _CMD struct {
-	cmd string
-	cwd string
+	cmd     string
+	cwd     string
+	timeout time.Duration
+	stream  bool
+
+	limiter *bearychat.Limiter
 }
 
 type Commands struct {
@@ -23,6 +26,9 @@ type Commands struct {
 	timeout  time.Duration
 
 	defaultCWD string
+
+	stream *streamOptions
+	procs  *processes
 }
 
 func init() {
@@ -44,10 +50,19 @@ func NewCommands(word string, config *configuration.Config) (bearychat.Trigger,
 			}
 
 			cmd := _CMD{
-				cmd: conf.GetString("cmd"),
-				cwd: conf.GetString("cwd"),
+				cmd:     conf.GetString("cmd"),
+				cwd:     conf.GetString("cwd"),
+				timeout: conf.GetTimeDuration("timeout", 0),
+				stream:  conf.GetBoolean("stream", false),
+			}
+
+			limiter, err := bearychat.NewLimiter(conf.GetConfig("concurrency"))
+			if err != nil {
+				return nil, err
 			}
 
+			cmd.limiter = limiter
+
 			namePath[commandNames[i]] = cmd
 		}
 	}
@@ -55,10 +70,17 @@ func NewCommands(word string, config *configuration.Config) (bearychat.Trigger,
 	cwd, _ := os.Getwd()
 	defaultCWD := config.GetString("cwd", cwd)
 
+	stream, err := newStreamOptions(config.GetConfig("stream"))
+	if err != nil {
+		return nil, err
+	}
+
 	return &Commands{
 		namepath:   namePath,
-		timeout:    config.GetTimeDuration("timeout", 30),
+		timeout:    config.GetTimeDuration("timeout", 30*time.Second),
 		defaultCWD: defaultCWD,
+		stream:     stream,
+		procs:      newProcesses(config.GetTimeDuration("grace", 5*time.Second)),
 	}, nil
 }
 
@@ -66,7 +88,7 @@ func (p *Commands) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message
 
 	args := strings.Split(req.Text, " ")
 	if len(args) <= 1 {
-		return errors.New("command argument is too less")
+		return bearychat.UserError(errors.New("command argument is too less"))
 	}
 
 	var newArgs []string
@@ -81,7 +103,7 @@ func (p *Commands) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message
 
 	cmd, exist := p.namepath[commandName]
 	if !exist {
-		return errors.New("command not exist")
+		return bearychat.UserError(errors.New("command not exist"))
 	}
 
 	cwd := cmd.cwd
@@ -89,7 +111,42 @@ func (p *Commands) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message
 		cwd = p.defaultCWD
 	}
 
-	result, err := execCommand(p.timeout, cwd, cmd.cmd, newArgs[2:]...)
+	release, err := cmd.limiter.Acquire(req)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	timeout := cmd.timeout
+	if timeout <= 0 {
+		timeout = p.timeout
+	}
+
+	if cmd.stream {
+		if p.stream == nil {
+			return errors.New("stream of command " + commandName + " enabled, but stream.url not set")
+		}
+
+		summary, exitCode, err := execCommandStream(p.procs, p.stream, req.ChannelName, timeout, cwd, cmd.cmd, newArgs[2:]...)
+
+		req.Annotate("exit_code", exitCode)
+
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				return err
+			}
+		}
+
+		msg.Text = summary
+		msg.Markdown = true
+
+		return nil
+	}
+
+	result, exitCode, err := execCommand(p.procs, timeout, cwd, cmd.cmd, newArgs[2:]...)
+
+	req.Annotate("exit_code", exitCode)
 
 	if err != nil {
 		return err
@@ -100,45 +157,30 @@ func (p *Commands) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message
 	return nil
 }
 
-func execCommand(timeout time.Duration, cwd string, name string, args ...string) (result string, err error) {
+func (p *Commands) Close() error {
+	return p.procs.Close()
+}
+
+func execCommand(procs *processes, timeout time.Duration, cwd string, name string, args ...string) (result string, exitCode int, err error) {
 
 	cmd := exec.Command(name, args...)
 	cmd.Dir = cwd
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return
-	}
-
 	outBuf := bytes.NewBuffer(nil)
 	errBuf := bytes.NewBuffer(nil)
 
-	err = cmd.Start()
-
-	if err != nil {
-		return
-	}
-
-	go io.Copy(outBuf, stdout)
-	go io.Copy(errBuf, stderr)
+	cmd.Stdout = outBuf
+	cmd.Stderr = errBuf
 
-	ch := make(chan struct{})
+	timedOut, err := procs.run(cmd, timeout)
 
-	go func(cmd *exec.Cmd) {
-		defer close(ch)
-		cmd.Wait()
-	}(cmd)
+	exitCode = -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
 
-	select {
-	case <-ch:
-	case <-time.After(timeout):
-		cmd.Process.Kill()
-		err = errors.New("execute timeout")
+	if timedOut {
+		err = bearychat.UserError(errors.New("execute timeout"))
 		return
 	}
 
@@ -146,8 +188,14 @@ func execCommand(timeout time.Duration, cwd string, name string, args ...string)
 	outString := outBuf.String()
 
 	if len(errStr) > 0 {
-		return "", errors.New(errStr)
+		return "", exitCode, errors.New(errStr)
+	}
+
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return "", exitCode, err
+		}
 	}
 
-	return outString, nil
+	return outString, exitCode, nil
 }
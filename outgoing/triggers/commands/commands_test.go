@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+func TestCommandsDefaultTimeout(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("echo is not available on windows")
+	}
+
+	trigger, err := NewCommands("!cmd", configuration.ParseString(`{
+		commands {
+			echo.cmd = "echo"
+		}
+	}`))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	commands := trigger.(*Commands)
+	defer commands.Close()
+
+	if commands.timeout != 30*time.Second {
+		t.Errorf("default timeout should be 30s, got %s", commands.timeout)
+	}
+
+	msg := bearychat.Message{}
+	if err = commands.Handle(&bearychat.OutgoingRequest{Text: "!cmd echo hi", TriggerWord: "!cmd"}, &msg); err != nil || msg.Text != "hi\n" {
+		t.Errorf("command should run with the default timeout, got %q (%v)", msg.Text, err)
+	}
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gogap/bearychat"
+)
+
+var (
+	ErrShuttingDown = bearychat.UserError(errors.New("service is shutting down, please try again later"))
+)
+
+type process struct {
+	cmd    *exec.Cmd
+	err    error
+	exited chan struct{}
+}
+
+// processes tracks the running children, so they could be terminated
+// gracefully on timeout or when the service shuts down.
+type processes struct {
+	grace   time.Duration
+	running map[*process]bool
+	closed  bool
+
+	sync.Mutex
+}
+
+func newProcesses(grace time.Duration) *processes {
+	return &processes{
+		grace:   grace,
+		running: make(map[*process]bool),
+	}
+}
+
+// run starts cmd and waits for it to exit, it is terminated if it does not
+// exit in time.
+func (p *processes) run(cmd *exec.Cmd, timeout time.Duration) (timedOut bool, err error) {
+	p.Lock()
+
+	if p.closed {
+		p.Unlock()
+		return false, ErrShuttingDown
+	}
+
+	setProcessGroup(cmd)
+
+	if err = cmd.Start(); err != nil {
+		p.Unlock()
+		return
+	}
+
+	proc := &process{cmd: cmd, exited: make(chan struct{})}
+	p.running[proc] = true
+
+	p.Unlock()
+
+	go func() {
+		proc.err = cmd.Wait()
+
+		p.Lock()
+		delete(p.running, proc)
+		p.Unlock()
+
+		close(proc.exited)
+	}()
+
+	select {
+	case <-proc.exited:
+	case <-time.After(timeout):
+		timedOut = true
+		p.terminate(proc)
+	}
+
+	return timedOut, proc.err
+}
+
+// terminate sends SIGTERM to the process group first and kills the group if
+// the process is still alive after the grace period, so the children started
+// by a shell script do not outlive it.
+func (p *processes) terminate(proc *process) {
+	if err := signalGroup(proc.cmd, syscall.SIGTERM); err != nil {
+		signalGroup(proc.cmd, syscall.SIGKILL)
+	}
+
+	select {
+	case <-proc.exited:
+		return
+	case <-time.After(p.grace):
+	}
+
+	signalGroup(proc.cmd, syscall.SIGKILL)
+	<-proc.exited
+}
+
+func (p *processes) Close() error {
+	p.Lock()
+
+	p.closed = true
+
+	var procs []*process
+	for proc := range p.running {
+		procs = append(procs, proc)
+	}
+
+	p.Unlock()
+
+	wg := sync.WaitGroup{}
+
+	for _, proc := range procs {
+		wg.Add(1)
+		go func(proc *process) {
+			defer wg.Done()
+			p.terminate(proc)
+		}(proc)
+	}
+
+	wg.Wait()
+
+	return nil
+}
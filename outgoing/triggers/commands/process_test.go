@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestProcessesTerminate(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("signals are not supported on windows")
+	}
+
+	procs := newProcesses(100 * time.Millisecond)
+
+	start := time.Now()
+
+	timedOut, _ := procs.run(exec.Command("sleep", "5"), 50*time.Millisecond)
+	if !timedOut || time.Since(start) > time.Second {
+		t.Error("process should be terminated by SIGTERM after timeout")
+		return
+	}
+
+	start = time.Now()
+
+	timedOut, _ = procs.run(exec.Command("sh", "-c", "trap '' TERM; sleep 5"), 50*time.Millisecond)
+	if !timedOut || time.Since(start) > time.Second {
+		t.Error("process ignoring SIGTERM should be killed after the grace period")
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		procs.run(exec.Command("sleep", "5"), time.Minute)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	procs.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("running process should be terminated on close")
+		return
+	}
+
+	if _, err := procs.run(exec.Command("true"), time.Second); err != ErrShuttingDown {
+		t.Errorf("expected ErrShuttingDown after close, got: %v", err)
+	}
+}
+
+func TestProcessesTerminateGroup(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are not supported on windows")
+	}
+
+	procs := newProcesses(100 * time.Millisecond)
+
+	// the sleep child keeps stdout open, Wait only returns once it is gone
+	cmd := exec.Command("sh", "-c", "sleep 5; true")
+	cmd.Stdout = &bytes.Buffer{}
+
+	start := time.Now()
+
+	timedOut, _ := procs.run(cmd, 50*time.Millisecond)
+	if !timedOut || time.Since(start) > time.Second {
+		t.Error("children of the process should be terminated with it")
+	}
+}
@@ -0,0 +1,245 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+var (
+	ansiExpr = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b[@-Z\\-_]`)
+)
+
+type streamOptions struct {
+	url      string
+	interval time.Duration
+	lines    int
+
+	client *bearychat.IncomingClient
+}
+
+func newStreamOptions(config *configuration.Config) (*streamOptions, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	url := config.GetString("url")
+	if len(url) == 0 {
+		return nil, errors.New("stream.url of gogap-commands is empty")
+	}
+
+	return &streamOptions{
+		url:      url,
+		interval: config.GetTimeDuration("interval", 5*time.Second),
+		lines:    int(config.GetInt32("lines", 20)),
+		client: bearychat.NewIncomingClient(
+			bearychat.TimeoutOption(config.GetTimeDuration("send-timeout", 10*time.Second)),
+			bearychat.RetryOption(int(config.GetInt32("retries", 2)), config.GetTimeDuration("retry-interval", time.Second)),
+		),
+	}, nil
+}
+
+// streamer collects the output of a running command and flushes it to the
+// channel through the incoming webhook every interval or every N lines. The
+// chunks are sent by the loop, so a slow webhook never blocks the command.
+type streamer struct {
+	opts    *streamOptions
+	channel string
+
+	buf     bytes.Buffer
+	lines   int
+	pending []string
+	failed  int
+
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+
+	sync.Mutex
+}
+
+func newStreamer(opts *streamOptions, channel string) *streamer {
+	s := &streamer{
+		opts:    opts,
+		channel: channel,
+		kick:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go s.loop()
+
+	return s
+}
+
+func (p *streamer) Write(b []byte) (n int, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	n, err = p.buf.Write(b)
+	p.lines += bytes.Count(b, []byte{'\n'})
+
+	if p.opts.lines > 0 && p.lines >= p.opts.lines {
+		p.cut(false)
+	}
+
+	return
+}
+
+func (p *streamer) loop() {
+	defer close(p.done)
+
+	var tick <-chan time.Time
+
+	if p.opts.interval > 0 {
+		ticker := time.NewTicker(p.opts.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			p.Lock()
+			p.cut(true)
+			p.Unlock()
+		case <-p.kick:
+			p.deliver()
+		case <-p.stop:
+			p.Lock()
+			p.cut(true)
+			p.Unlock()
+			p.deliver()
+			return
+		}
+	}
+}
+
+// Close stops the loop and sends everything still buffered, it returns the
+// number of chunks that could not be delivered.
+func (p *streamer) Close() int {
+	close(p.stop)
+	<-p.done
+
+	p.Lock()
+	defer p.Unlock()
+
+	return p.failed
+}
+
+// cut moves the buffered complete lines, or everything buffered if partial
+// is true, to the pending chunks. The caller must hold the lock.
+func (p *streamer) cut(partial bool) {
+	data := p.buf.Bytes()
+
+	if !partial {
+		idx := bytes.LastIndexByte(data, '\n')
+		if idx < 0 {
+			return
+		}
+		data = data[:idx+1]
+	}
+
+	text := strings.TrimRight(stripANSI(string(data)), "\n")
+
+	p.buf.Next(len(data))
+	p.lines = bytes.Count(p.buf.Bytes(), []byte{'\n'})
+
+	if len(strings.TrimSpace(text)) == 0 {
+		return
+	}
+
+	p.pending = append(p.pending, text)
+
+	select {
+	case p.kick <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the pending chunks in order, without holding the lock while
+// sending.
+func (p *streamer) deliver() {
+	for {
+		p.Lock()
+		if len(p.pending) == 0 {
+			p.Unlock()
+			return
+		}
+		text := p.pending[0]
+		p.pending = p.pending[1:]
+		p.Unlock()
+
+		msg := &bearychat.Message{
+			Text:     "```\n" + text + "\n```",
+			Markdown: true,
+			Channel:  p.channel,
+		}
+
+		resp, err := p.opts.client.Send(p.opts.url, msg)
+		if err == nil {
+			err = resp.Err()
+		}
+
+		if err != nil {
+			p.Lock()
+			p.failed++
+			p.Unlock()
+		}
+	}
+}
+
+func stripANSI(str string) string {
+	str = ansiExpr.ReplaceAllString(str, "")
+	return strings.Replace(str, "\r", "", -1)
+}
+
+func execCommandStream(procs *processes, opts *streamOptions, channel string, timeout time.Duration, cwd string, name string, args ...string) (summary string, exitCode int, err error) {
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = cwd
+
+	out := newStreamer(opts, channel)
+
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	start := time.Now()
+
+	cmdLine := strings.TrimSpace(name + " " + strings.Join(args, " "))
+
+	timedOut, err := procs.run(cmd, timeout)
+
+	failed := out.Close()
+
+	if cmd.Process == nil {
+		return fmt.Sprintf("`%s` failed to start: %s", cmdLine, err.Error()), -1, err
+	}
+
+	duration := time.Since(start)
+
+	exitCode = -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if timedOut {
+		summary = fmt.Sprintf("`%s` terminated after timeout of %s", cmdLine, timeout)
+	} else {
+		summary = fmt.Sprintf("`%s` exited with status %d in %s", cmdLine, exitCode, duration.Truncate(time.Millisecond))
+	}
+
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d output chunk(s) could not be delivered", failed)
+	}
+
+	return
+}
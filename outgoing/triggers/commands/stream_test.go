@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogap/bearychat"
+	"github.com/gogap/bearychat/incoming/fake"
+)
+
+func newTestStream(t *testing.T, interval time.Duration, lines int, opts ...fake.Option) (*streamOptions, *fake.Server) {
+	server := fake.NewServer(append([]fake.Option{fake.HooksOption("/hook")}, opts...)...)
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	return &streamOptions{
+		url:      ts.URL + "/hook",
+		interval: interval,
+		lines:    lines,
+		client:   bearychat.NewIncomingClient(),
+	}, server
+}
+
+func TestStreamerLines(t *testing.T) {
+
+	opts, server := newTestStream(t, 0, 2)
+
+	out := newStreamer(opts, "ops")
+	out.Write([]byte("a\nb\nc\nd"))
+
+	if failed := out.Close(); failed != 0 {
+		t.Errorf("all chunks should be delivered, %d failed", failed)
+	}
+
+	msgs := server.Messages()
+	if len(msgs) != 2 || msgs[0].Text != "```\na\nb\nc\n```" || msgs[1].Text != "```\nd\n```" || msgs[0].Channel != "ops" {
+		t.Errorf("complete lines and the rest should be sent as two chunks: %+v", msgs)
+	}
+}
+
+func TestStreamerInterval(t *testing.T) {
+
+	opts, server := newTestStream(t, 20*time.Millisecond, 0)
+
+	out := newStreamer(opts, "ops")
+	defer out.Close()
+
+	out.Write([]byte("\x1b[31mred\x1b[0m\r"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(server.Messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if msgs := server.Messages(); len(msgs) != 1 || msgs[0].Text != "```\nred\n```" {
+		t.Errorf("partial output should be flushed on interval without ANSI codes: %+v", msgs)
+	}
+}
+
+func TestStreamerSlowWebhook(t *testing.T) {
+
+	opts, server := newTestStream(t, 0, 1, fake.LatencyOption(200*time.Millisecond))
+
+	out := newStreamer(opts, "ops")
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		out.Write([]byte("line\n"))
+	}
+
+	if time.Since(start) > 100*time.Millisecond {
+		t.Error("writes should not wait for the webhook")
+	}
+
+	out.Close()
+
+	if msgs := server.Messages(); len(msgs) != 3 {
+		t.Errorf("all chunks should be delivered on close, got %d", len(msgs))
+	}
+}
+
+func TestExecCommandStream(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on windows")
+	}
+
+	opts, server := newTestStream(t, 0, 10)
+
+	summary, exitCode, err := execCommandStream(newProcesses(time.Second), opts, "ops", time.Second, "", "sh", "-c", "echo hi; exit 3")
+	if err == nil || exitCode != 3 || !strings.Contains(summary, "exited with status 3") {
+		t.Errorf("summary should report the exit status: %q %d %v", summary, exitCode, err)
+	}
+
+	if msgs := server.Messages(); len(msgs) != 1 || msgs[0].Text != "```\nhi\n```" {
+		t.Errorf("output should be streamed: %+v", msgs)
+	}
+
+	summary, exitCode, err = execCommandStream(newProcesses(time.Second), opts, "ops", time.Second, "", "/nonexistent/command")
+	if err == nil || exitCode != -1 || !strings.Contains(summary, "failed to start") {
+		t.Errorf("start failure should be returned: %q %d %v", summary, exitCode, err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-akka/configuration"
 	"github.com/gogap/bearychat"
+	"github.com/gogap/bearychat/internal/metrics"
 )
 
 type Confirm struct {
@@ -61,14 +62,16 @@ func (p *Confirm) randomHandle(req *bearychat.OutgoingRequest, resp *bearychat.M
 	resp.Text = fmt.Sprintf("%s: %d", p.prompt, rnd)
 
 	p.Lock()
-	p.currentHandlers[req.UserName] = p.generateComfirmRandomHandle(rnd, *p.beforeReq)
+	p.currentHandlers[req.UserName] = p.generateComfirmRandomHandle(rnd, *p.beforeReq, req.Now())
 	p.Unlock()
 
+	metrics.Challenge("gogap-confirm", "challenged")
+	req.Annotate("confirm", "challenged")
+
 	return bearychat.ErrBreakOnly
 }
 
-func (p *Confirm) generateComfirmRandomHandle(number int32, before bearychat.OutgoingRequest) bearychat.TriggerHandleFunc {
-	now := time.Now()
+func (p *Confirm) generateComfirmRandomHandle(number int32, before bearychat.OutgoingRequest, now time.Time) bearychat.TriggerHandleFunc {
 	num := number
 	originalReq := before
 
@@ -83,32 +86,39 @@ func (p *Confirm) generateComfirmRandomHandle(number int32, before bearychat.Out
 			return p.defaultHandler(req, msg)
 		}
 
-		if time.Now().Sub(now).Seconds() > 30 {
+		if req.Now().Sub(now).Seconds() > 30 {
+			metrics.Challenge("gogap-confirm", "expired")
+			req.Annotate("confirm_expired", true)
 			return p.defaultHandler(req, msg)
 		}
 
 		args := req.Args()
 
 		if len(args) != 1 {
-			return errors.New(p.prompt)
+			return bearychat.UserError(errors.New(p.prompt))
 		}
 
 		strNum := args[0]
 
 		n, err := strconv.Atoi(strNum)
 		if err != nil {
-			return errors.New(p.prompt)
+			return bearychat.UserError(errors.New(p.prompt))
 		}
 
 		if n == 0 {
-			return errors.New(p.prompt)
+			return bearychat.UserError(errors.New(p.prompt))
 		}
 
 		if num != int32(n) {
-			return errors.New("bad comfirm numbers")
+			metrics.Challenge("gogap-confirm", "rejected")
+			req.Annotate("confirm", "rejected")
+			return bearychat.UserError(errors.New("bad comfirm numbers"))
 		}
 
+		metrics.Challenge("gogap-confirm", "confirmed")
+
 		*req = before
+		req.Annotate("confirm", "confirmed")
 		return nil
 	}
 
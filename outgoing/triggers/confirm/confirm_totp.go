@@ -9,10 +9,11 @@ import (
 
 	"github.com/go-akka/configuration"
 	"github.com/gogap/bearychat"
+	"github.com/gogap/bearychat/internal/metrics"
 )
 
 var (
-	UserTOTPSecretNotExist = errors.New("user totp secret not exist")
+	UserTOTPSecretNotExist = bearychat.PermissionDenied(errors.New("user totp secret not exist"))
 )
 
 type TOTPConfirm struct {
@@ -75,6 +76,8 @@ func (p *TOTPConfirm) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Mess
 func (p *TOTPConfirm) totpHandle(req *bearychat.OutgoingRequest, msg *bearychat.Message) (err error) {
 
 	if secret := p.userSecret[req.UserName]; len(secret) == 0 {
+		metrics.Challenge("gogap-confirm-totp", "no_secret")
+		req.Annotate("confirm", "no_secret")
 		err = UserTOTPSecretNotExist
 		return
 	}
@@ -84,14 +87,16 @@ func (p *TOTPConfirm) totpHandle(req *bearychat.OutgoingRequest, msg *bearychat.
 	msg.Text = p.prompt
 
 	p.Lock()
-	p.currentHandlers[req.UserName] = p.generateComfirmHandle(*p.beforeReq)
+	p.currentHandlers[req.UserName] = p.generateComfirmHandle(*p.beforeReq, req.Now())
 	p.Unlock()
 
+	metrics.Challenge("gogap-confirm-totp", "challenged")
+	req.Annotate("confirm", "challenged")
+
 	return bearychat.ErrBreakOnly
 }
 
-func (p *TOTPConfirm) generateComfirmHandle(before bearychat.OutgoingRequest) bearychat.TriggerHandleFunc {
-	now := time.Now()
+func (p *TOTPConfirm) generateComfirmHandle(before bearychat.OutgoingRequest, now time.Time) bearychat.TriggerHandleFunc {
 	originalReq := before
 
 	fn := func(req *bearychat.OutgoingRequest, msg *bearychat.Message) error {
@@ -105,14 +110,16 @@ func (p *TOTPConfirm) generateComfirmHandle(before bearychat.OutgoingRequest) be
 			return p.defaultHandler(req, msg)
 		}
 
-		if time.Now().Sub(now).Seconds() > float64(p.period) {
+		if req.Now().Sub(now).Seconds() > float64(p.period) {
+			metrics.Challenge("gogap-confirm-totp", "expired")
+			req.Annotate("confirm_expired", true)
 			return p.defaultHandler(req, msg)
 		}
 
 		args := req.Args()
 
 		if len(args) != 1 {
-			return errors.New(p.prompt)
+			return bearychat.UserError(errors.New(p.prompt))
 		}
 
 		passcode := args[0]
@@ -121,7 +128,7 @@ func (p *TOTPConfirm) generateComfirmHandle(before bearychat.OutgoingRequest) be
 		if rv, _ := totp.ValidateCustom(
 			passcode,
 			secret,
-			time.Now().UTC(),
+			req.Now().UTC(),
 			totp.ValidateOpts{
 				Period:    uint(p.period),
 				Skew:      1,
@@ -129,10 +136,15 @@ func (p *TOTPConfirm) generateComfirmHandle(before bearychat.OutgoingRequest) be
 				Algorithm: otp.AlgorithmSHA1,
 			},
 		); !rv {
-			return errors.New("bad one time password ")
+			metrics.Challenge("gogap-confirm-totp", "rejected")
+			req.Annotate("confirm", "rejected")
+			return bearychat.UserError(errors.New("bad one time password "))
 		}
 
+		metrics.Challenge("gogap-confirm-totp", "confirmed")
+
 		*req = before
+		req.Annotate("confirm", "confirmed")
 		return nil
 	}
 
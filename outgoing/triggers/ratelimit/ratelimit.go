@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+const (
+	defaultMessage = "{{.User}}, you are sending commands too fast, please retry in {{.RetryAfter}}"
+)
+
+type RateLimit struct {
+	word string
+
+	keys   []string
+	burst  int
+	refill time.Duration
+
+	message *template.Template
+	exempt  map[string]bool
+
+	store Store
+}
+
+type messageData struct {
+	User       string
+	Channel    string
+	Word       string
+	RetryAfter time.Duration
+}
+
+func init() {
+	bearychat.RegisterTriggerDriver("gogap-ratelimit", NewRateLimit)
+}
+
+func NewRateLimit(word string, config *configuration.Config) (bearychat.Trigger, error) {
+
+	if config == nil {
+		config = configuration.ParseString("{}")
+	}
+
+	keys := config.GetStringList("key")
+	if len(keys) == 0 {
+		keys = []string{config.GetString("key", "user")}
+	}
+
+	for i := 0; i < len(keys); i++ {
+		switch keys[i] {
+		case "user", "channel", "word":
+		default:
+			return nil, fmt.Errorf("gogap-ratelimit: unknown key of %s, should be user, channel or word", keys[i])
+		}
+	}
+
+	message, err := template.New("gogap-ratelimit").Parse(config.GetString("message", defaultMessage))
+	if err != nil {
+		return nil, err
+	}
+
+	exempt := make(map[string]bool)
+	for _, user := range config.GetStringList("exempt") {
+		exempt[user] = true
+	}
+
+	store, err := newStore(config.GetString("store.driver", "memory"), config.GetConfig("store"))
+	if err != nil {
+		return nil, err
+	}
+
+	burst := int(config.GetInt32("burst", 5))
+	if burst <= 0 {
+		return nil, errors.New("gogap-ratelimit: burst should be greater than 0")
+	}
+
+	return &RateLimit{
+		word:    word,
+		keys:    keys,
+		burst:   burst,
+		refill:  config.GetTimeDuration("refill", 10*time.Second),
+		message: message,
+		exempt:  exempt,
+		store:   store,
+	}, nil
+}
+
+func (p *RateLimit) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message) (err error) {
+
+	if p.exempt[req.UserName] {
+		return
+	}
+
+	ok, retryAfter, err := p.store.Take(p.key(req), p.burst, p.refill, req.Now())
+	if err != nil {
+		return
+	}
+
+	if ok {
+		return
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	err = p.message.Execute(buf, messageData{
+		User:       req.UserName,
+		Channel:    req.ChannelName,
+		Word:       p.word,
+		RetryAfter: retryAfter.Truncate(time.Second) + time.Second,
+	})
+
+	if err != nil {
+		return
+	}
+
+	return bearychat.UserError(errors.New(buf.String()))
+}
+
+func (p *RateLimit) key(req *bearychat.OutgoingRequest) string {
+	parts := make([]string, 0, len(p.keys)+1)
+	parts = append(parts, p.word)
+
+	for i := 0; i < len(p.keys); i++ {
+		switch p.keys[i] {
+		case "user":
+			parts = append(parts, "user:"+req.UserName)
+		case "channel":
+			parts = append(parts, "channel:"+req.ChannelName)
+		}
+	}
+
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat"
+)
+
+func TestRateLimit(t *testing.T) {
+
+	limit, err := NewRateLimit("!cmd", configuration.ParseString(`{
+		key    = [user, channel]
+		burst  = 2
+		refill = 1h
+		exempt = [admin]
+	}`))
+
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	req := &bearychat.OutgoingRequest{TriggerWord: "!cmd", UserName: "zeal", ChannelName: "ops"}
+
+	for i := 0; i < 2; i++ {
+		if err = limit.Handle(req, &bearychat.Message{}); err != nil {
+			t.Errorf("request %d should pass: %s", i, err)
+			return
+		}
+	}
+
+	if err = limit.Handle(req, &bearychat.Message{}); err == nil {
+		t.Error("third request should be limited")
+		return
+	}
+
+	other := &bearychat.OutgoingRequest{TriggerWord: "!cmd", UserName: "zeal", ChannelName: "dev"}
+	if err = limit.Handle(other, &bearychat.Message{}); err != nil {
+		t.Errorf("other channel should have its own bucket: %s", err)
+		return
+	}
+
+	admin := &bearychat.OutgoingRequest{TriggerWord: "!cmd", UserName: "admin", ChannelName: "ops"}
+	for i := 0; i < 5; i++ {
+		if err = limit.Handle(admin, &bearychat.Message{}); err != nil {
+			t.Errorf("exempt user should not be limited: %s", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-akka/configuration"
+)
+
+var (
+	ErrStoreAlreadyRegistered = errors.New("rate limit store already registered")
+	ErrNewStoreFuncIsNil      = errors.New("new store func is nil")
+)
+
+// Store keeps the token buckets, implementations backed by a shared storage
+// could be registered to share limits across replicas.
+type Store interface {
+	Take(key string, burst int, refill time.Duration, now time.Time) (ok bool, retryAfter time.Duration, err error)
+}
+
+type NewStoreFunc func(config *configuration.Config) (Store, error)
+
+var (
+	storeFuncs = make(map[string]NewStoreFunc)
+)
+
+func init() {
+	RegisterStore("memory", NewMemoryStore)
+}
+
+func RegisterStore(name string, fn NewStoreFunc) {
+	if fn == nil {
+		panic(ErrNewStoreFuncIsNil)
+	}
+
+	if _, exist := storeFuncs[name]; exist {
+		panic(ErrStoreAlreadyRegistered)
+	}
+
+	storeFuncs[name] = fn
+}
+
+func Stores() []string {
+	var ret []string
+	for k := range storeFuncs {
+		ret = append(ret, k)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+func newStore(name string, config *configuration.Config) (Store, error) {
+	fn, exist := storeFuncs[name]
+	if !exist {
+		return nil, fmt.Errorf("rate limit store of %s did not exist", name)
+	}
+
+	return fn(config)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type MemoryStore struct {
+	buckets map[string]*bucket
+	takes   int
+
+	sync.Mutex
+}
+
+func NewMemoryStore(config *configuration.Config) (Store, error) {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+	}, nil
+}
+
+func (p *MemoryStore) Take(key string, burst int, refill time.Duration, now time.Time) (ok bool, retryAfter time.Duration, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.takes++
+	if p.takes%1024 == 0 {
+		p.sweep(now, burst, refill)
+	}
+
+	b, exist := p.buckets[key]
+	if !exist {
+		b = &bucket{tokens: float64(burst), last: now}
+		p.buckets[key] = b
+	}
+
+	if refill > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(refill)
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	if refill <= 0 {
+		return false, 0, nil
+	}
+
+	return false, time.Duration((1 - b.tokens) * float64(refill)), nil
+}
+
+// sweep drops the buckets which are full again, they are equal to new ones.
+func (p *MemoryStore) sweep(now time.Time, burst int, refill time.Duration) {
+	if refill <= 0 {
+		return
+	}
+
+	for key, b := range p.buckets {
+		if b.tokens+float64(now.Sub(b.last))/float64(refill) >= float64(burst) {
+			delete(p.buckets, key)
+		}
+	}
+}
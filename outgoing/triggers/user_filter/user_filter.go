@@ -40,7 +40,7 @@ func NewUserFilter(word string, config *configuration.Config) (bearychat.Trigger
 func (p *UserFilter) Handle(req *bearychat.OutgoingRequest, msg *bearychat.Message) (err error) {
 
 	if !p.users[req.UserName] {
-		err = errors.New("gogap-user-filter: permision denied.")
+		err = bearychat.PermissionDenied(errors.New("gogap-user-filter: permision denied."))
 		return
 	}
 
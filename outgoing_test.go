@@ -23,7 +23,7 @@ func NewGreeter(word string, config *configuration.Config) (Trigger, error) {
 	}, nil
 }
 
-func (p *Greeter) Handle(req *Request, resp *Response) error {
+func (p *Greeter) Handle(req *OutgoingRequest, resp *Message) error {
 	switch req.TriggerWord {
 	case "!hello":
 		{
@@ -74,19 +74,19 @@ func TestBasicOutgoingRequest(t *testing.T) {
 		return
 	}
 
-	req1 := &Request{
+	req1 := &OutgoingRequest{
 		Text:        "!hello my name is zeal",
 		UserName:    "zeal",
 		TriggerWord: "!hello",
 	}
 
-	req2 := &Request{
+	req2 := &OutgoingRequest{
 		Text:        "!hello my name is gogap",
 		UserName:    "gogap",
 		TriggerWord: "!morning",
 	}
 
-	resp1 := Response{}
+	resp1 := Message{}
 	err = outgoing.Handle(req1, &resp1)
 
 	if err != nil {
@@ -99,7 +99,7 @@ func TestBasicOutgoingRequest(t *testing.T) {
 		return
 	}
 
-	resp2 := Response{}
+	resp2 := Message{}
 	err = outgoing.Handle(req2, &resp2)
 	if err != nil {
 		t.Error(err)
@@ -111,3 +111,19 @@ func TestBasicOutgoingRequest(t *testing.T) {
 		return
 	}
 }
+
+func TestDetectTriggerWord(t *testing.T) {
+
+	words := []string{"!deploy", "!deploy-all"}
+
+	for text, expected := range map[string]string{
+		"!deploy web":      "!deploy",
+		" !deploy-all":     "!deploy-all",
+		"!unknown command": "!unknown",
+		"":                 "",
+	} {
+		if word := DetectTriggerWord(words, text); word != expected {
+			t.Errorf("%q: expected %q, got %q", text, expected, word)
+		}
+	}
+}
@@ -3,12 +3,15 @@ package bearychat
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type Image struct {
 	URL string `json:"url"`
 }
 
+// Attachment has all the fields of an attachment documented for incoming
+// messages and outgoing replies, testdata/message.json covers them.
 type Attachment struct {
 	Title  string  `json:"title"`
 	Text   string  `json:"text"`
@@ -16,6 +19,8 @@ type Attachment struct {
 	Images []Image `json:"images"`
 }
 
+// Message is the reply of an outgoing request or the payload of an incoming
+// webhook, BearyChat documents no other fields for them.
 type Message struct {
 	Text         string       `json:"text"`
 	Notification string       `json:"notification"`
@@ -25,15 +30,65 @@ type Message struct {
 	Attachments  []Attachment `json:"attachments"`
 }
 
+// OutgoingRequest is posted by the outgoing robot, the ids are only sent by
+// newer BearyChat versions and stay empty otherwise.
 type OutgoingRequest struct {
 	Token       string   `json:"token"`
-	Timestamp   int      `json:"ts"`
+	Timestamp   int64    `json:"ts"`
 	Text        string   `json:"text"`
 	TriggerWord string   `json:"trigger_word"`
 	Subdomain   string   `json:"subdomain"`
 	ChannelName string   `json:"channel_name"`
 	UserName    string   `json:"user_name"`
+	UserID      string   `json:"user_id,omitempty"`
+	ChannelID   string   `json:"channel_id,omitempty"`
+	VChannelID  string   `json:"vchannel_id,omitempty"`
 	Commands    []string `json:"-"`
+	ID          string   `json:"-"`
+
+	annotations map[string]interface{}
+
+	// the raw request as received over http, kept for the recorder
+	contentType string
+	body        []byte
+
+	clock func() time.Time
+}
+
+// Now returns the current time of the clock of the Outgoing handling the
+// request, drivers should use it instead of time.Now, see ClockOption.
+func (p *OutgoingRequest) Now() time.Time {
+	if p.clock == nil {
+		return time.Now()
+	}
+
+	return p.clock()
+}
+
+// Time returns the time of ts, which is sent in seconds or, by newer
+// versions, in milliseconds. It is zero if ts is not set.
+func (p *OutgoingRequest) Time() time.Time {
+	switch {
+	case p.Timestamp <= 0:
+		return time.Time{}
+	case p.Timestamp > 1e12:
+		return time.Unix(0, p.Timestamp*int64(time.Millisecond))
+	}
+
+	return time.Unix(p.Timestamp, 0)
+}
+
+// Annotate attaches a detail to the request, the details are written to the
+// audit log.
+func (p *OutgoingRequest) Annotate(key string, value interface{}) {
+	if p.annotations == nil {
+		p.annotations = make(map[string]interface{})
+	}
+	p.annotations[key] = value
+}
+
+func (p *OutgoingRequest) Annotations() map[string]interface{} {
+	return p.annotations
 }
 
 func (p *OutgoingRequest) Args() []string {
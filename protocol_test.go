@@ -0,0 +1,62 @@
+package bearychat
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProtocolRoundTrip(t *testing.T) {
+
+	for file, v := range map[string]interface{}{
+		"outgoing_request.json":     &OutgoingRequest{},
+		"outgoing_request_ids.json": &OutgoingRequest{},
+		"message.json":              &Message{},
+		"incoming_response.json":    &IncomingResponse{},
+	} {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if err = json.Unmarshal(data, v); err != nil {
+			t.Errorf("%s: %s", file, err)
+			continue
+		}
+
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			t.Errorf("%s: %s", file, err)
+			continue
+		}
+
+		var expected, actual interface{}
+		json.Unmarshal(data, &expected)
+		json.Unmarshal(encoded, &actual)
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%s: round trip changed the payload:\n%s", file, encoded)
+		}
+	}
+}
+
+func TestOutgoingRequestTime(t *testing.T) {
+
+	for _, c := range []struct {
+		ts       int64
+		expected time.Time
+	}{
+		{0, time.Time{}},
+		{1355517523, time.Unix(1355517523, 0)},
+		{1500000000123, time.Unix(1500000000, 123*int64(time.Millisecond))},
+	} {
+		req := &OutgoingRequest{Timestamp: c.ts}
+		if !req.Time().Equal(c.expected) {
+			t.Errorf("ts %d: expected %s, got %s", c.ts, c.expected, req.Time())
+		}
+	}
+}
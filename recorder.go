@@ -0,0 +1,171 @@
+package bearychat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat/internal"
+	"github.com/gogap/bearychat/internal/rotate"
+)
+
+type Recorder interface {
+	Record(rec *Recording) error
+}
+
+// Recording is a request as it was received together with the reply. The
+// token of the request is replaced by "******" in Request and Body, and
+// TokenRedacted is set.
+type Recording struct {
+	Time          time.Time       `json:"time"`
+	ID            string          `json:"request_id"`
+	Service       string          `json:"service,omitempty"`
+	Request       OutgoingRequest `json:"request"`
+	ContentType   string          `json:"content_type,omitempty"`
+	Body          string          `json:"body,omitempty"`
+	TokenRedacted bool            `json:"token_redacted,omitempty"`
+	Status        int             `json:"status"`
+	Message       Message         `json:"message"`
+}
+
+// DecodeRequest returns the recorded request decoded from the raw body, or
+// Request if the body was not recorded, e.g. for requests which were not
+// received over http.
+func (p *Recording) DecodeRequest() (*OutgoingRequest, error) {
+	if len(p.Body) == 0 {
+		req := p.Request
+		req.ID = p.ID
+		return &req, nil
+	}
+
+	req, err := decodeOutgoingRequest(p.ContentType, []byte(p.Body), false)
+	if err != nil {
+		return nil, err
+	}
+
+	req.ID = p.ID
+
+	return req, nil
+}
+
+// JSONRecorder writes recordings as JSON lines.
+type JSONRecorder struct {
+	w io.Writer
+
+	sync.Mutex
+}
+
+func NewJSONRecorder(w io.Writer) *JSONRecorder {
+	return &JSONRecorder{w: w}
+}
+
+func (p *JSONRecorder) Record(rec *Recording) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	_, err = p.w.Write(append(data, '\n'))
+
+	return err
+}
+
+func (p *JSONRecorder) Close() error {
+	if closer, ok := p.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// NewRecorder creates a JSON lines recorder writing to a rotating file:
+//
+//	record {
+//	    file        = "/var/log/outgoing/requests.jsonl"
+//	    max-size    = 100MB
+//	    max-backups = 10
+//	}
+func NewRecorder(config *configuration.Config) (*JSONRecorder, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	filename := config.GetString("file")
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("record.file is empty")
+	}
+
+	maxSize, err := internal.ByteSize(config, "max-size", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := rotate.Open(filename, maxSize, int(config.GetInt32("max-backups", 10)))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONRecorder(f), nil
+}
+
+// ReadRecordings decodes the recordings written by JSONRecorder.
+func ReadRecordings(r io.Reader) ([]*Recording, error) {
+	var recs []*Recording
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	for decoder.More() {
+		rec := &Recording{}
+		if err := decoder.Decode(rec); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs, nil
+}
+
+func (p *Outgoing) record(req *OutgoingRequest, statusCode int, msg Message) {
+	rec := &Recording{
+		Time:        p.settings.Clock(),
+		ID:          req.ID,
+		Service:     p.settings.Service,
+		Request:     *req,
+		ContentType: req.contentType,
+		Body:        string(redactToken(req.body, req.Token)),
+		Status:      statusCode,
+		Message:     msg,
+	}
+
+	if len(req.Token) > 0 {
+		rec.Request.Token = redacted
+		rec.TokenRedacted = true
+	}
+
+	if err := p.settings.Recorder.Record(rec); err != nil {
+		log.Printf("[bearychat] write recording failed, request_id: %s, error: %s", req.ID, err.Error())
+	}
+}
+
+func redactToken(body []byte, token string) []byte {
+	if len(token) == 0 {
+		return body
+	}
+
+	body = bytes.Replace(body, []byte(token), []byte(redacted), -1)
+
+	if escaped := url.QueryEscape(token); escaped != token {
+		body = bytes.Replace(body, []byte(escaped), []byte(redacted), -1)
+	}
+
+	return body
+}
@@ -0,0 +1,95 @@
+package bearychat
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestRecorder(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		deploy {
+			word = "!ops"
+			commands = [deploy]
+			drivers = [test-annotator]
+		}
+	}`)
+
+	buf := bytes.NewBuffer(nil)
+
+	outgoing, err := NewOutgoing(config, RegistryOption(testRegistry()), RecorderOption(NewJSONRecorder(buf)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	req := &OutgoingRequest{Text: "!ops deploy web", TriggerWord: "!ops", UserName: "zeal", Token: "secret"}
+	status, msg := outgoing.Reply(req)
+
+	recs, err := ReadRecordings(buf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(recs) != 1 {
+		t.Errorf("expected 1 recording, got %d", len(recs))
+		return
+	}
+
+	rec := recs[0]
+
+	if rec.ID != req.ID || rec.Status != status || rec.Message.Text != msg.Text {
+		t.Errorf("bad recording: %+v", rec)
+	}
+
+	if rec.Request.Text != "!ops deploy web" || rec.Request.Token != "******" || !rec.TokenRedacted || len(rec.Request.Commands) > 0 {
+		t.Errorf("the request should be recorded as received with the token redacted: %+v", rec.Request)
+	}
+}
+
+func TestRecorderRawBody(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		deploy {
+			word = "!ops"
+			drivers = [test-annotator]
+		}
+	}`)
+
+	buf := bytes.NewBuffer(nil)
+
+	outgoing, err := NewOutgoing(config, RegistryOption(testRegistry()), RecorderOption(NewJSONRecorder(buf)))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	body := "token=s%2Fcret&trigger_word=%21ops&text=%21ops+deploy&user_name=zeal&extra=kept"
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	outgoing.HandleHttpRequest(httptest.NewRecorder(), req)
+
+	recs, err := ReadRecordings(buf)
+	if err != nil || len(recs) != 1 {
+		t.Errorf("expected 1 recording: %v", err)
+		return
+	}
+
+	rec := recs[0]
+
+	if strings.Contains(rec.Body, "cret") || !strings.Contains(rec.Body, "extra=kept") {
+		t.Errorf("raw body should be recorded with the token redacted: %s", rec.Body)
+	}
+
+	decoded, err := rec.DecodeRequest()
+	if err != nil || decoded.Text != "!ops deploy" || decoded.Token != "******" || decoded.ID != rec.ID {
+		t.Errorf("recorded body should be decoded: %+v %v", decoded, err)
+	}
+}
@@ -0,0 +1,86 @@
+package bearychat
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	// DefaultRegistry holds the drivers registered by RegisterTriggerDriver,
+	// it is used by every Outgoing created without RegistryOption.
+	DefaultRegistry = NewRegistry()
+)
+
+// Registry maps driver names to the funcs creating the triggers.
+type Registry struct {
+	funcs map[string]NewTriggerFunc
+
+	sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		funcs: make(map[string]NewTriggerFunc),
+	}
+}
+
+func (p *Registry) Register(name string, fn NewTriggerFunc) error {
+	if fn == nil {
+		return ErrNewTriggerFuncIsNil
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if _, exist := p.funcs[name]; exist {
+		return ErrTriggerDriverAlreadyRegistered
+	}
+
+	p.funcs[name] = fn
+
+	return nil
+}
+
+func (p *Registry) Unregister(name string) {
+	p.Lock()
+	defer p.Unlock()
+
+	delete(p.funcs, name)
+}
+
+func (p *Registry) Lookup(name string) (NewTriggerFunc, bool) {
+	p.RLock()
+	defer p.RUnlock()
+
+	fn, exist := p.funcs[name]
+	return fn, exist
+}
+
+// Drivers returns the sorted names of the registered drivers.
+func (p *Registry) Drivers() []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	var ret []string
+	for name := range p.funcs {
+		ret = append(ret, name)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+// Clone returns a registry with the drivers of p, e.g. to add drivers to
+// the default ones for one Outgoing only.
+func (p *Registry) Clone() *Registry {
+	p.RLock()
+	defer p.RUnlock()
+
+	r := NewRegistry()
+	for name, fn := range p.funcs {
+		r.funcs[name] = fn
+	}
+
+	return r
+}
@@ -0,0 +1,125 @@
+package bearychat
+
+import (
+	"testing"
+
+	"github.com/go-akka/configuration"
+	"github.com/gogap/bearychat/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type constant struct {
+	text string
+}
+
+func (p *constant) Handle(req *OutgoingRequest, msg *Message) error {
+	msg.Text = p.text
+	return nil
+}
+
+func newConstant(text string) NewTriggerFunc {
+	return func(word string, config *configuration.Config) (Trigger, error) {
+		return &constant{text: text}, nil
+	}
+}
+
+// testRegistry returns the default drivers and the fake ones of the tests,
+// which are kept out of DefaultRegistry.
+func testRegistry() *Registry {
+	registry := DefaultRegistry.Clone()
+
+	registry.Register("test-annotator", newAnnotator)
+	registry.Register("test-counter", NewCounter)
+	registry.Register("test-failure", newFailure)
+
+	return registry
+}
+
+func TestRegistry(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		hello {
+			word = "!hello"
+			drivers = [fake]
+		}
+	}`)
+
+	first := NewRegistry()
+	second := DefaultRegistry.Clone()
+
+	if err := first.Register("fake", newConstant("first")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := first.Register("fake", newConstant("again")); err != ErrTriggerDriverAlreadyRegistered {
+		t.Errorf("duplicates should be rejected, got: %v", err)
+	}
+
+	if err := second.Register("fake", newConstant("second")); err != nil {
+		t.Error(err)
+		return
+	}
+
+	for registry, expected := range map[*Registry]string{first: "first", second: "second"} {
+		outgoing, _ := NewOutgoing(config, RegistryOption(registry))
+
+		msg := Message{}
+		if err := outgoing.Handle(&OutgoingRequest{Text: "!hello", TriggerWord: "!hello"}, &msg); err != nil || msg.Text != expected {
+			t.Errorf("expected %q, got %q (%v)", expected, msg.Text, err)
+		}
+	}
+
+	if _, exist := DefaultRegistry.Lookup("fake"); exist {
+		t.Error("instance registries should not change the default one")
+	}
+
+	if drivers := second.Drivers(); len(drivers) != len(TriggerDrivers())+1 {
+		t.Errorf("clone should keep the default drivers: %v", drivers)
+	}
+
+	first.Unregister("fake")
+
+	if drivers := first.Drivers(); len(drivers) != 0 {
+		t.Errorf("unregistered driver still listed: %v", drivers)
+	}
+}
+
+func TestRegistryNestedOutgoing(t *testing.T) {
+
+	config := configuration.ParseString(`{
+		ops {
+			word = "!ops"
+			drivers = [gogap-outgoing]
+			gogap-outgoing {
+				hello {
+					word = "!ops"
+					commands = [hello]
+					drivers = [fake]
+				}
+			}
+		}
+	}`)
+
+	registry := DefaultRegistry.Clone()
+	registry.Register("fake", newConstant("nested"))
+
+	outgoing, _ := NewOutgoing(config, RegistryOption(registry), ServiceOption("nested"))
+	defer outgoing.Close()
+
+	requests := metrics.Requests.WithLabelValues("nested", "", "!ops", "", "ok")
+	count := testutil.ToFloat64(requests)
+
+	msg := Message{}
+	if err := outgoing.Handle(&OutgoingRequest{Text: "!ops hello", TriggerWord: "!ops"}, &msg); err != nil || msg.Text != "nested" {
+		t.Errorf("nested outgoing should use the registry of its parent, got %q (%v)", msg.Text, err)
+	}
+
+	if n := testutil.ToFloat64(requests) - count; n != 1 {
+		t.Errorf("request should be counted once, got %v", n)
+	}
+
+	if nested, ok := outgoing.bindings()[0].triggers[0].(*Outgoing); !ok || nested.settings.Registry != registry || nested.settings.Logger != nil {
+		t.Errorf("nested outgoing should inherit the settings: %+v", outgoing.bindings()[0].triggers[0])
+	}
+}
@@ -0,0 +1,105 @@
+package bearychat
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// TeamRouter serves several BearyChat teams from one endpoint, requests are
+// handled by the Outgoing of their subdomain, or by the fallback one.
+type TeamRouter struct {
+	teams    map[string]*Outgoing
+	fallback *Outgoing
+}
+
+func NewTeamRouter(fallback *Outgoing) *TeamRouter {
+	return &TeamRouter{
+		teams:    make(map[string]*Outgoing),
+		fallback: fallback,
+	}
+}
+
+func (p *TeamRouter) AddTeam(subdomain string, outgoing *Outgoing) *TeamRouter {
+	p.teams[strings.ToLower(subdomain)] = outgoing
+	return p
+}
+
+func (p *TeamRouter) Outgoing(subdomain string) *Outgoing {
+	if outgoing, exist := p.teams[strings.ToLower(subdomain)]; exist {
+		return outgoing
+	}
+
+	return p.fallback
+}
+
+func (p *TeamRouter) Handle(req *OutgoingRequest, msg *Message) error {
+	return p.Outgoing(req.Subdomain).Handle(req, msg)
+}
+
+// Words returns the trigger words bound by any of the teams.
+func (p *TeamRouter) Words() []string {
+	seen := make(map[string]bool)
+
+	var words []string
+	for _, outgoing := range append(p.outgoings(), p.fallback) {
+		for _, word := range outgoing.Words() {
+			if !seen[word] {
+				seen[word] = true
+				words = append(words, word)
+			}
+		}
+	}
+
+	sort.Strings(words)
+
+	return words
+}
+
+// Token returns a token accepted by the team of req, see Outgoing.Token.
+func (p *TeamRouter) Token(req *OutgoingRequest) string {
+	return p.Outgoing(req.Subdomain).Token(req)
+}
+
+func (p *TeamRouter) Reply(req *OutgoingRequest) (int, Message) {
+	return p.Outgoing(req.Subdomain).Reply(req)
+}
+
+func (p *TeamRouter) Execute(req *OutgoingRequest) (int, Message, error) {
+	return p.Outgoing(req.Subdomain).Execute(req)
+}
+
+func (p *TeamRouter) HandleHttpRequest(rw http.ResponseWriter, req *http.Request) {
+
+	triggerReq, ok := p.fallback.decodeHttpRequest(rw, req)
+	if !ok {
+		return
+	}
+
+	p.Outgoing(triggerReq.Subdomain).writeReply(rw, triggerReq)
+}
+
+func (p *TeamRouter) Close() error {
+	var errs []string
+
+	for _, outgoing := range append(p.outgoings(), p.fallback) {
+		if err := outgoing.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (p *TeamRouter) outgoings() []*Outgoing {
+	var ret []*Outgoing
+	for _, outgoing := range p.teams {
+		ret = append(ret, outgoing)
+	}
+	return ret
+}
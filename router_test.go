@@ -0,0 +1,55 @@
+package bearychat
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-akka/configuration"
+)
+
+func TestTeamRouter(t *testing.T) {
+
+	newOutgoing := func(command string) *Outgoing {
+		config := configuration.ParseString(`{
+			ping {
+				word = "!cmd"
+				commands = [` + command + `]
+				drivers = [test-counter]
+			}
+		}`)
+
+		outgoing, err := NewOutgoing(config, RegistryOption(testRegistry()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return outgoing
+	}
+
+	router := NewTeamRouter(newOutgoing("ping")).
+		AddTeam("acme", newOutgoing("pong"))
+
+	send := func(subdomain, text string) Message {
+		body := `{"text":"` + text + `","trigger_word":"!cmd","subdomain":"` + subdomain + `"}`
+		rw := httptest.NewRecorder()
+		router.HandleHttpRequest(rw, httptest.NewRequest("POST", "/triggers", strings.NewReader(body)))
+
+		msg := Message{}
+		json.Unmarshal(rw.Body.Bytes(), &msg)
+		return msg
+	}
+
+	if msg := send("acme", "!cmd pong"); msg.Text != "1" {
+		t.Errorf("acme should be routed to its own outgoing, got: %s", msg.Text)
+	}
+
+	if msg := send("ACME", "!cmd ping"); !strings.Contains(msg.Text, "unknown sub-command") {
+		t.Errorf("acme should not serve the default triggers, got: %s", msg.Text)
+	}
+
+	if msg := send("other", "!cmd ping"); msg.Text != "1" {
+		t.Errorf("unknown subdomain should fall back to the default outgoing, got: %s", msg.Text)
+	}
+}
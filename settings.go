@@ -1,14 +1,120 @@
 package bearychat
 
 import (
+	"time"
+
 	"github.com/go-akka/configuration"
 )
 
 type OutgoingOption func(*OutgoingSettings)
 
 type OutgoingSettings struct {
+	Service       string
+	Team          string
+	Logger        RequestLogger
+	MaxBodySize   int64
+	ErrorTemplate *ErrorTemplate
+	Audit         AuditSink
+	Recorder      Recorder
+	Strict        bool
+	Registry      *Registry
+	Clock         func() time.Time
+
+	// set for the Outgoing of a gogap-outgoing driver, whose requests are
+	// counted by the parent
+	nested bool
+}
+
+func NewOutgoingSettings(config *configuration.Config, opts ...OutgoingOption) *OutgoingSettings {
+	settings := &OutgoingSettings{}
+
+	for i := 0; i < len(opts); i++ {
+		opts[i](settings)
+	}
+
+	if settings.Clock == nil {
+		settings.Clock = time.Now
+	}
+
+	return settings
+}
+
+// ClockOption replaces time.Now as the clock of the Outgoing, which is used
+// for expirations, e.g. of confirmations, rate limits and deduplication, and
+// passed to the drivers by OutgoingRequest.Now. Tests use it to advance the
+// time instead of sleeping.
+func ClockOption(now func() time.Time) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Clock = now
+	}
+}
+
+// MaxBodySizeOption limits the size of the request body read by
+// HandleHttpRequest, larger requests are answered with 413.
+func MaxBodySizeOption(size int64) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.MaxBodySize = size
+	}
+}
+
+// ServiceOption names the service the Outgoing is mounted as, the name is
+// carried by request logs and metrics.
+func ServiceOption(service string) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Service = service
+	}
+}
+
+// ErrorTemplateOption sets the error template of the triggers which do not
+// configure their own.
+func ErrorTemplateOption(tmpl *ErrorTemplate) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.ErrorTemplate = tmpl
+	}
+}
+
+// TeamOption names the team served by the Outgoing, the name is carried by
+// request logs and metrics.
+func TeamOption(team string) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Team = team
+	}
+}
+
+func LoggerOption(logger RequestLogger) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Logger = logger
+	}
+}
+
+// AuditOption writes an audit entry for every request which matched a
+// trigger.
+func AuditOption(sink AuditSink) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Audit = sink
+	}
+}
+
+// RecorderOption records the requests answered by Reply together with the
+// replies, see `outgoing replay`.
+func RecorderOption(recorder Recorder) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Recorder = recorder
+	}
+}
+
+// StrictOption makes HandleHttpRequest reject requests with unknown fields
+// or without token, trigger_word or text.
+func StrictOption(strict bool) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Strict = strict
+	}
 }
 
-func NewOutgoingSettings(config *configuration.Config) *OutgoingSettings {
-	return &OutgoingSettings{}
+// RegistryOption creates the triggers of the config by the drivers of
+// registry instead of DefaultRegistry.
+func RegistryOption(registry *Registry) OutgoingOption {
+	return func(s *OutgoingSettings) {
+		s.Registry = registry
+	}
 }
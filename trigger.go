@@ -1,7 +1,27 @@
 package bearychat
 
+import (
+	"time"
+)
+
 type TriggerHandleFunc func(req *OutgoingRequest, msg *Message) (err error)
 
 type Trigger interface {
 	Handle(*OutgoingRequest, *Message) error
 }
+
+// Handle calls fn, so that functions can be bound like triggers.
+func (fn TriggerHandleFunc) Handle(req *OutgoingRequest, msg *Message) error {
+	return fn(req, msg)
+}
+
+// Middleware wraps the handling of every matched request, e.g. for logging
+// or permission checks.
+type Middleware func(next TriggerHandleFunc) TriggerHandleFunc
+
+// TokenProvider is implemented by triggers which authenticate requests by a
+// token, e.g. gogap-auth, so that tools simulating or replaying requests can
+// fill in a valid token.
+type TokenProvider interface {
+	Token(now time.Time) string
+}